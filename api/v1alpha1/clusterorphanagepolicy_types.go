@@ -0,0 +1,121 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterOrphanagePolicySpec defines the desired state of ClusterOrphanagePolicy.
+type ClusterOrphanagePolicySpec struct {
+	// NamespaceSelector selects the namespaces to scan by their labels.
+	// When neither NamespaceSelector nor IncludeNamespaces is set, all namespaces are scanned.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// IncludeNamespaces lists namespaces that are scanned even if they do not match NamespaceSelector
+	// +optional
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+	// ExcludeNamespaces lists namespaces that are never scanned. It takes precedence over
+	// NamespaceSelector and IncludeNamespaces.
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// ResourceTypes specifies the Kubernetes resource types to monitor and how each of them is scanned.
+	// Each kind may be listed at most once. When empty, all supported kinds are monitored.
+	// +listType=map
+	// +listMapKey=kind
+	// +optional
+	ResourceTypes []ResourceTypeSpec `json:"resourceTypes,omitempty"`
+	// Exclusions describes resources that are never reported as orphans
+	// +optional
+	Exclusions *Exclusions `json:"exclusions,omitempty"`
+	// GracePeriod is how long a resource must stay unreferenced before it is reported as an orphan.
+	// Until then it is listed as pending, so resources created shortly before their consumers are not reported.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// NamespaceOrphans represents the orphaned resources found in a single namespace
+type NamespaceOrphans struct {
+	// Namespace is the name of the scanned namespace
+	Namespace string `json:"namespace"`
+	// OrphanCount is the number of orphaned resources in the namespace
+	OrphanCount int `json:"orphanCount,omitempty"`
+	// Orphans is the list of orphaned resources in the namespace
+	Orphans []Orphan `json:"orphans,omitempty"`
+	// PendingCount is the number of unreferenced resources in the namespace still held back by the grace period
+	PendingCount int `json:"pendingCount,omitempty"`
+	// Pending is the list of unreferenced resources in the namespace still held back by the grace period
+	Pending []Orphan `json:"pending,omitempty"`
+	// ExcludedCount is the number of unreferenced resources in the namespace skipped because they matched an exclusion rule
+	ExcludedCount int `json:"excludedCount,omitempty"`
+	// Excluded is the list of unreferenced resources in the namespace skipped because they matched an exclusion rule
+	Excluded []Orphan `json:"excluded,omitempty"`
+	// RollbackOnlyCount is the number of resources in the namespace only referenced by revision history
+	RollbackOnlyCount int `json:"rollbackOnlyCount,omitempty"`
+	// RollbackOnly is the list of resources in the namespace only referenced by revision history
+	RollbackOnly []Orphan `json:"rollbackOnly,omitempty"`
+	// ListsTruncated is set when the Excluded or RollbackOnly list was cut to its first entries to keep
+	// the status small. The counts always cover every resource.
+	// +optional
+	ListsTruncated bool `json:"listsTruncated,omitempty"`
+}
+
+// ClusterOrphanagePolicyStatus defines the observed state of ClusterOrphanagePolicy.
+type ClusterOrphanagePolicyStatus struct {
+	ScanStatus `json:",inline"`
+	// OrphanCount is the total number of orphaned resources across all scanned namespaces
+	OrphanCount int `json:"orphanCount,omitempty"`
+	// LastChanged is the timestamp when the set of reported orphans last changed
+	LastChanged metav1.Time `json:"lastChanged,omitempty"`
+	// PendingCount is the total number of unreferenced resources still held back by the grace period
+	PendingCount int `json:"pendingCount,omitempty"`
+	// ExcludedCount is the total number of unreferenced resources skipped because they matched an exclusion rule
+	ExcludedCount int `json:"excludedCount,omitempty"`
+	// RollbackOnlyCount is the total number of resources only referenced by revision history
+	RollbackOnlyCount int `json:"rollbackOnlyCount,omitempty"`
+	// Namespaces is the per-namespace breakdown of orphaned resources
+	Namespaces []NamespaceOrphans `json:"namespaces,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Orphans",type=integer,JSONPath=`.status.orphanCount`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterOrphanagePolicy is the Schema for the clusterorphanagepolicies API.
+type ClusterOrphanagePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterOrphanagePolicySpec   `json:"spec,omitempty"`
+	Status ClusterOrphanagePolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterOrphanagePolicyList contains a list of ClusterOrphanagePolicy.
+type ClusterOrphanagePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterOrphanagePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterOrphanagePolicy{}, &ClusterOrphanagePolicyList{})
+}
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -34,33 +35,167 @@ const (
 	ResourceTypeConfigMap ResourceType = "ConfigMap"
 )
 
+// ResourceTypeSpec configures how resources of a single kind are scanned for orphans
+type ResourceTypeSpec struct {
+	// Kind is the Kubernetes resource kind to monitor
+	Kind ResourceType `json:"kind"`
+	// Selector restricts the scan to resources whose labels match this selector
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// ExcludeNames lists names of resources that are never reported as orphans
+	// +optional
+	ExcludeNames []string `json:"excludeNames,omitempty"`
+	// MinAge is the minimum age a resource must reach before it can be reported as an orphan
+	// +optional
+	MinAge *metav1.Duration `json:"minAge,omitempty"`
+}
+
+// Exclusions describes resources that are intentionally unreferenced, such as backup keys
+// or bootstrap tokens, and must never be reported as orphans.
+// A resource is excluded if it matches any of the rules.
+type Exclusions struct {
+	// LabelSelector excludes resources whose labels match this selector
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// Annotations excludes resources that carry any of these annotations with the given value.
+	// The kponos.io/ignore: "true" annotation is always honored.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// NamePatterns excludes resources whose name matches any of these glob patterns (e.g. "backup-*")
+	// +optional
+	NamePatterns []string `json:"namePatterns,omitempty"`
+	// NameRegexes excludes resources whose name matches any of these regular expressions
+	// +optional
+	NameRegexes []string `json:"nameRegexes,omitempty"`
+}
+
 // OrphanagePolicySpec defines the desired state of OrphanagePolicy.
 type OrphanagePolicySpec struct {
-	// ResourceTypes specifies the Kubernetes resource types to monitor
-	// Supported values: "Secret", "ConfigMap"
-	ResourceTypes []ResourceType `json:"resourceTypes,omitempty"`
+	// ResourceTypes specifies the Kubernetes resource types to monitor and how each of them is scanned.
+	// Each kind may be listed at most once. When empty, all supported kinds are monitored.
+	// +listType=map
+	// +listMapKey=kind
+	// +optional
+	ResourceTypes []ResourceTypeSpec `json:"resourceTypes,omitempty"`
+	// Exclusions describes resources that are never reported as orphans
+	// +optional
+	Exclusions *Exclusions `json:"exclusions,omitempty"`
+	// GracePeriod is how long a resource must stay unreferenced before it is reported as an orphan.
+	// Until then it is listed as pending, so resources created shortly before their consumers are not reported.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
 }
 
 // Orphan represents an orphaned resource
 type Orphan struct {
-	// Kind is the Kubernetes resource kind (e.g., "Secret", "ConfigMap")
+	// APIVersion is the API version of the orphaned resource (e.g., "v1")
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind is the Kubernetes resource kind (e.g., "Secret", "ConfigMap"), or the kind of the resource generating
+	// an unreferenced Secret (e.g., "ExternalSecret")
 	Kind string `json:"kind"`
+	// Namespace is the namespace of the orphaned resource
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 	// Name is the name of the orphaned resource
 	Name string `json:"name"`
+	// UID is the UID of the orphaned resource. It distinguishes the resource from a recreated one with the same name.
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+	// CreationTimestamp is the time the orphaned resource was created
+	// +optional
+	CreationTimestamp *metav1.Time `json:"creationTimestamp,omitempty"`
+	// Type is the type of an orphaned Secret (e.g., "Opaque", "kubernetes.io/tls")
+	// +optional
+	Type string `json:"type,omitempty"`
+	// CreatorLabels are the labels of the orphaned resource that identify the tool that created it (Helm, Argo CD, kustomize, ...)
+	// +optional
+	CreatorLabels map[string]string `json:"creatorLabels,omitempty"`
+	// SizeBytes is the approximate size of the data held by the orphaned resource in bytes
+	// +optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+	// FirstSeenOrphaned is the time of the first scan that found the resource unreferenced
+	// +optional
+	FirstSeenOrphaned *metav1.Time `json:"firstSeenOrphaned,omitempty"`
+	// Reason is a machine-readable explanation of why the resource is orphaned (e.g., "Unreferenced", "ServiceAccountDeleted")
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable explanation of why the resource is orphaned
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// ConditionReady indicates that the policy has been scanned successfully and its status is up to date
+	ConditionReady = "Ready"
+	// ConditionScanSucceeded indicates whether the last scan of the policy completed
+	ConditionScanSucceeded = "ScanSucceeded"
+	// ConditionDegraded indicates that the last scan failed and the reported orphans may be stale
+	ConditionDegraded = "Degraded"
+)
+
+const (
+	// ReasonScanSucceeded is used when the last scan completed
+	ReasonScanSucceeded = "ScanSucceeded"
+	// ReasonScanFailed is used when the last scan failed, e.g. because the manager is not allowed to list a resource
+	ReasonScanFailed = "ScanFailed"
+	// ReasonInvalidSpec is used when the policy spec cannot be applied, e.g. because of an invalid selector
+	ReasonInvalidSpec = "InvalidSpec"
+)
+
+// ScanStatus describes the outcome of the last scan of a policy
+type ScanStatus struct {
+	// Conditions represent the latest available observations of the policy's state
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the most recent generation of the policy spec that was scanned
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastScanDuration is how long the last scan took. It is only written together with other
+	// status changes, so a scan that finds nothing new does not update the status.
+	// +optional
+	LastScanDuration *metav1.Duration `json:"lastScanDuration,omitempty"`
+	// LastError is the error of the last scan; empty when the last scan succeeded
+	// +optional
+	LastError string `json:"lastError,omitempty"`
 }
 
 // OrphanagePolicyStatus defines the observed state of OrphanagePolicy.
 type OrphanagePolicyStatus struct {
+	ScanStatus `json:",inline"`
 	// OrphanCount is the total number of orphaned resources
 	OrphanCount int `json:"orphanCount,omitempty"`
-	// LastChanged is the timestamp when the status was last updated
+	// LastChanged is the timestamp when the set of reported orphans last changed
 	LastChanged metav1.Time `json:"lastChanged,omitempty"`
 	// Orphans is the list of orphaned resources
 	Orphans []Orphan `json:"orphans,omitempty"`
+	// PendingCount is the number of unreferenced resources still held back by the grace period
+	PendingCount int `json:"pendingCount,omitempty"`
+	// Pending is the list of unreferenced resources still held back by the grace period
+	Pending []Orphan `json:"pending,omitempty"`
+	// ExcludedCount is the number of unreferenced resources skipped because they matched an exclusion rule
+	ExcludedCount int `json:"excludedCount,omitempty"`
+	// Excluded is the list of unreferenced resources skipped because they matched an exclusion rule
+	Excluded []Orphan `json:"excluded,omitempty"`
+	// RollbackOnlyCount is the number of resources only referenced by revision history
+	RollbackOnlyCount int `json:"rollbackOnlyCount,omitempty"`
+	// RollbackOnly is the list of resources only referenced by revision history, such as old ReplicaSets
+	// of a Deployment or ControllerRevisions of a StatefulSet or DaemonSet. They are not in use,
+	// but a rollback would need them.
+	RollbackOnly []Orphan `json:"rollbackOnly,omitempty"`
+	// ListsTruncated is set when the Excluded or RollbackOnly list was cut to its first entries to keep
+	// the status small. The counts always cover every resource.
+	// +optional
+	ListsTruncated bool `json:"listsTruncated,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Orphans",type=integer,JSONPath=`.status.orphanCount`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // OrphanagePolicy is the Schema for the orphanagepolicies API.
 type OrphanagePolicy struct {
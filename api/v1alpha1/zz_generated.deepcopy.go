@@ -21,12 +21,238 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterOrphanagePolicy) DeepCopyInto(out *ClusterOrphanagePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterOrphanagePolicy.
+func (in *ClusterOrphanagePolicy) DeepCopy() *ClusterOrphanagePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterOrphanagePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterOrphanagePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterOrphanagePolicyList) DeepCopyInto(out *ClusterOrphanagePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterOrphanagePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterOrphanagePolicyList.
+func (in *ClusterOrphanagePolicyList) DeepCopy() *ClusterOrphanagePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterOrphanagePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterOrphanagePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterOrphanagePolicySpec) DeepCopyInto(out *ClusterOrphanagePolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IncludeNamespaces != nil {
+		in, out := &in.IncludeNamespaces, &out.IncludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceTypes != nil {
+		in, out := &in.ResourceTypes, &out.ResourceTypes
+		*out = make([]ResourceTypeSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclusions != nil {
+		in, out := &in.Exclusions, &out.Exclusions
+		*out = new(Exclusions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterOrphanagePolicySpec.
+func (in *ClusterOrphanagePolicySpec) DeepCopy() *ClusterOrphanagePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterOrphanagePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterOrphanagePolicyStatus) DeepCopyInto(out *ClusterOrphanagePolicyStatus) {
+	*out = *in
+	in.ScanStatus.DeepCopyInto(&out.ScanStatus)
+	in.LastChanged.DeepCopyInto(&out.LastChanged)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NamespaceOrphans, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterOrphanagePolicyStatus.
+func (in *ClusterOrphanagePolicyStatus) DeepCopy() *ClusterOrphanagePolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterOrphanagePolicyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Exclusions) DeepCopyInto(out *Exclusions) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NamePatterns != nil {
+		in, out := &in.NamePatterns, &out.NamePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NameRegexes != nil {
+		in, out := &in.NameRegexes, &out.NameRegexes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Exclusions.
+func (in *Exclusions) DeepCopy() *Exclusions {
+	if in == nil {
+		return nil
+	}
+	out := new(Exclusions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceOrphans) DeepCopyInto(out *NamespaceOrphans) {
+	*out = *in
+	if in.Orphans != nil {
+		in, out := &in.Orphans, &out.Orphans
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Excluded != nil {
+		in, out := &in.Excluded, &out.Excluded
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RollbackOnly != nil {
+		in, out := &in.RollbackOnly, &out.RollbackOnly
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceOrphans.
+func (in *NamespaceOrphans) DeepCopy() *NamespaceOrphans {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceOrphans)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Orphan) DeepCopyInto(out *Orphan) {
 	*out = *in
+	if in.CreationTimestamp != nil {
+		in, out := &in.CreationTimestamp, &out.CreationTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CreatorLabels != nil {
+		in, out := &in.CreatorLabels, &out.CreatorLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FirstSeenOrphaned != nil {
+		in, out := &in.FirstSeenOrphaned, &out.FirstSeenOrphaned
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Orphan.
@@ -103,8 +329,20 @@ func (in *OrphanagePolicySpec) DeepCopyInto(out *OrphanagePolicySpec) {
 	*out = *in
 	if in.ResourceTypes != nil {
 		in, out := &in.ResourceTypes, &out.ResourceTypes
-		*out = make([]ResourceType, len(*in))
-		copy(*out, *in)
+		*out = make([]ResourceTypeSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Exclusions != nil {
+		in, out := &in.Exclusions, &out.Exclusions
+		*out = new(Exclusions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
 	}
 }
 
@@ -121,11 +359,35 @@ func (in *OrphanagePolicySpec) DeepCopy() *OrphanagePolicySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OrphanagePolicyStatus) DeepCopyInto(out *OrphanagePolicyStatus) {
 	*out = *in
+	in.ScanStatus.DeepCopyInto(&out.ScanStatus)
 	in.LastChanged.DeepCopyInto(&out.LastChanged)
 	if in.Orphans != nil {
 		in, out := &in.Orphans, &out.Orphans
 		*out = make([]Orphan, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Excluded != nil {
+		in, out := &in.Excluded, &out.Excluded
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RollbackOnly != nil {
+		in, out := &in.RollbackOnly, &out.RollbackOnly
+		*out = make([]Orphan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -138,3 +400,60 @@ func (in *OrphanagePolicyStatus) DeepCopy() *OrphanagePolicyStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceTypeSpec) DeepCopyInto(out *ResourceTypeSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExcludeNames != nil {
+		in, out := &in.ExcludeNames, &out.ExcludeNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MinAge != nil {
+		in, out := &in.MinAge, &out.MinAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceTypeSpec.
+func (in *ResourceTypeSpec) DeepCopy() *ResourceTypeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceTypeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScanStatus) DeepCopyInto(out *ScanStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastScanDuration != nil {
+		in, out := &in.LastScanDuration, &out.LastScanDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScanStatus.
+func (in *ScanStatus) DeepCopy() *ScanStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScanStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -26,11 +26,14 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -40,6 +43,7 @@ import (
 	orphanagev1alpha1 "github.com/toKrzysztof/kponos/api/v1alpha1"
 	application "github.com/toKrzysztof/kponos/internal/application/orphanage"
 	"github.com/toKrzysztof/kponos/internal/controller"
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
 	presentation "github.com/toKrzysztof/kponos/internal/presentation"
 	// +kubebuilder:scaffold:imports
 )
@@ -66,6 +70,8 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	var ingressAnnotationReferences string
+	var certManagerClusterResourceNamespace string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -83,6 +89,13 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&ingressAnnotationReferences, "ingress-annotation-references", core.DefaultIngressAnnotationReferences,
+		"Comma-separated annotation=Kind pairs naming the Ingress annotations that reference a Secret or ConfigMap, "+
+			"with Kind Secret or ConfigMap. Defaults to the annotations of ingress-nginx.")
+	flag.StringVar(&certManagerClusterResourceNamespace, "cert-manager-cluster-resource-namespace",
+		core.DefaultCertManagerClusterResourceNamespace,
+		"The namespace in which cert-manager looks up the Secrets of ClusterIssuers. "+
+			"Set it to the --cluster-resource-namespace of cert-manager.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -187,6 +200,20 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "0c33fee0.kponos.io",
+		// Only metadata of Secrets and ConfigMaps is needed to find orphans, so their payload is
+		// dropped before it reaches the cache. Managed fields are never needed.
+		Cache: cache.Options{
+			DefaultTransform: cache.TransformStripManagedFields(),
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Secret{}:    {Transform: application.StripPayload},
+				&corev1.ConfigMap{}: {Transform: application.StripPayload},
+			},
+		},
+		// Optional kinds such as cert-manager or Gateway API resources are read as unstructured
+		// objects; serve them from the cache like every other kind instead of the API server.
+		Client: client.Options{
+			Cache: &client.CacheOptions{Unstructured: true},
+		},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -204,7 +231,24 @@ func main() {
 		os.Exit(1)
 	}
 
-	orphanage := application.NewOrphanage(mgr.GetClient())
+	// The optional kinds are resolved once; kinds whose CRDs are installed later are picked up after a restart
+	servedKinds, err := core.ResolveServedKinds(mgr.GetRESTMapper())
+	if err != nil {
+		setupLog.Error(err, "unable to discover the served optional kinds")
+		os.Exit(1)
+	}
+
+	annotationReferences, err := core.ParseAnnotationReferences(ingressAnnotationReferences)
+	if err != nil {
+		setupLog.Error(err, "invalid --ingress-annotation-references")
+		os.Exit(1)
+	}
+
+	orphanage := application.NewOrphanage(mgr.GetClient(), core.Options{
+		ServedKinds:                         servedKinds,
+		IngressAnnotationReferences:         annotationReferences,
+		CertManagerClusterResourceNamespace: certManagerClusterResourceNamespace,
+	})
 	statusWriter := presentation.NewStatusWriter(mgr.GetClient())
 
 	if err := (&controller.OrphanagePolicyReconciler{
@@ -212,10 +256,21 @@ func main() {
 		Scheme:       mgr.GetScheme(),
 		Orphanage:    orphanage,
 		StatusWriter: statusWriter,
+		ServedKinds:  servedKinds,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OrphanagePolicy")
 		os.Exit(1)
 	}
+	if err := (&controller.ClusterOrphanagePolicyReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Orphanage:    orphanage,
+		StatusWriter: statusWriter,
+		ServedKinds:  servedKinds,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterOrphanagePolicy")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -0,0 +1,59 @@
+package application
+
+import (
+	"path"
+	"regexp"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IgnoreAnnotation marks a resource as intentionally unreferenced when set to "true"
+const IgnoreAnnotation = "kponos.io/ignore"
+
+// Exclusions describes resources that are intentionally unreferenced and never reported as orphans.
+// A resource is excluded if it matches any of the rules.
+type Exclusions struct {
+	// Selector excludes resources with matching labels; nil excludes nothing
+	Selector labels.Selector
+	// Annotations excludes resources that carry any of these annotations with the given value
+	Annotations map[string]string
+	// NamePatterns excludes resources whose name matches any of these glob patterns
+	NamePatterns []string
+	// NameRegexes excludes resources whose name matches any of these regular expressions
+	NameRegexes []*regexp.Regexp
+}
+
+// Matches checks if a resource matches any of the exclusion rules.
+// The kponos.io/ignore: "true" annotation always matches.
+func (e Exclusions) Matches(resource client.Object) bool {
+	annotations := resource.GetAnnotations()
+	if annotations[IgnoreAnnotation] == "true" {
+		return true
+	}
+
+	for key, value := range e.Annotations {
+		if actual, exists := annotations[key]; exists && actual == value {
+			return true
+		}
+	}
+
+	if e.Selector != nil && e.Selector.Matches(labels.Set(resource.GetLabels())) {
+		return true
+	}
+
+	name := resource.GetName()
+	for _, pattern := range e.NamePatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	for _, regex := range e.NameRegexes {
+		if regex.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 
 	resourceHandler "github.com/toKrzysztof/kponos/internal/application/orphanage/internal/internal"
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 type ResourceHandler interface {
-	// FindReferences finds all resources that reference a given Secret or ConfigMap
-	FindReferences(ctx context.Context, c client.Client, resource client.Object, namespace string) ([]client.Object, error)
+	// CollectReferences adds every Secret and ConfigMap referenced by resources of this type in the namespace to the graph
+	CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error
 
 	// GetResourceType returns the resource type this handler processes
 	GetResourceType() string
@@ -20,17 +22,51 @@ type HandlerRegistry struct {
 	handlers map[string]ResourceHandler
 }
 
-// NewHandlerRegistry creates a new handler registry with all handlers initialized
-func NewHandlerRegistry(c client.Client) *HandlerRegistry {
+// NewHandlerRegistry creates a new handler registry with all handlers initialized.
+// The handlers share the given reference analyzer.
+func NewHandlerRegistry(c client.Client, analyzer *core.ReferenceAnalyzer) *HandlerRegistry {
 	return &HandlerRegistry{
 		// TODO: replace strings with strictly typed enums
 		handlers: map[string]ResourceHandler{
-			"Pod":            resourceHandler.NewPodHandler(c),
-			"Deployment":     resourceHandler.NewDeploymentHandler(c),
-			"StatefulSet":    resourceHandler.NewStatefulSetHandler(c),
-			"DaemonSet":      resourceHandler.NewDaemonSetHandler(c),
-			"Ingress":        resourceHandler.NewIngressHandler(c),
-			"ServiceAccount": resourceHandler.NewServiceAccountHandler(c),
+			"Pod":                   resourceHandler.NewPodHandler(c, analyzer),
+			"Deployment":            resourceHandler.NewDeploymentHandler(c, analyzer),
+			"StatefulSet":           resourceHandler.NewStatefulSetHandler(c, analyzer),
+			"DaemonSet":             resourceHandler.NewDaemonSetHandler(c, analyzer),
+			"Job":                   resourceHandler.NewJobHandler(c, analyzer),
+			"CronJob":               resourceHandler.NewCronJobHandler(c, analyzer),
+			"ReplicaSet":            resourceHandler.NewReplicaSetHandler(c, analyzer),
+			"ReplicationController": resourceHandler.NewReplicationControllerHandler(c, analyzer),
+			"PodTemplate":           resourceHandler.NewPodTemplateHandler(c, analyzer),
+			"ControllerRevision":    resourceHandler.NewControllerRevisionHandler(c, analyzer),
+			"Ingress":               resourceHandler.NewIngressHandler(c, analyzer),
+			"IngressClass":          resourceHandler.NewIngressClassHandler(c, analyzer),
+			"Gateway":               resourceHandler.NewGatewayHandler(c, analyzer),
+			"BackendTLSPolicy":      resourceHandler.NewBackendTLSPolicyHandler(c, analyzer),
+			"Issuer":                resourceHandler.NewIssuerHandler(c, analyzer),
+			"ClusterIssuer":         resourceHandler.NewClusterIssuerHandler(c, analyzer),
+			"Certificate":           resourceHandler.NewCertificateHandler(c, analyzer),
+			"ExternalSecret":        resourceHandler.NewExternalSecretHandler(c, analyzer),
+			"PushSecret":            resourceHandler.NewPushSecretHandler(c, analyzer),
+			"SecretStore":           resourceHandler.NewSecretStoreHandler(c, analyzer),
+			"ClusterSecretStore":    resourceHandler.NewClusterSecretStoreHandler(c, analyzer),
+			"VaultStaticSecret":     resourceHandler.NewVaultStaticSecretHandler(c, analyzer),
+			"VaultDynamicSecret":    resourceHandler.NewVaultDynamicSecretHandler(c, analyzer),
+			"Prometheus":            resourceHandler.NewPrometheusHandler(c, analyzer),
+			"Alertmanager":          resourceHandler.NewAlertmanagerHandler(c, analyzer),
+			"AlertmanagerConfig":    resourceHandler.NewAlertmanagerConfigHandler(c, analyzer),
+			"ServiceMonitor":        resourceHandler.NewServiceMonitorHandler(c, analyzer),
+			"PodMonitor":            resourceHandler.NewPodMonitorHandler(c, analyzer),
+			"Probe":                 resourceHandler.NewProbeHandler(c, analyzer),
+			"GitRepository":         resourceHandler.NewGitRepositoryHandler(c, analyzer),
+			"HelmRepository":        resourceHandler.NewHelmRepositoryHandler(c, analyzer),
+			"OCIRepository":         resourceHandler.NewOCIRepositoryHandler(c, analyzer),
+			"Kustomization":         resourceHandler.NewKustomizationHandler(c, analyzer),
+			"HelmRelease":           resourceHandler.NewHelmReleaseHandler(c, analyzer),
+			"ArgoCD":                resourceHandler.NewArgoCDHandler(c, analyzer),
+			"ServiceAccount":        resourceHandler.NewServiceAccountHandler(c, analyzer),
+			"StorageClass":          resourceHandler.NewStorageClassHandler(c, analyzer),
+			"PersistentVolume":      resourceHandler.NewPersistentVolumeHandler(c, analyzer),
+			"VolumeSnapshotClass":   resourceHandler.NewVolumeSnapshotClassHandler(c, analyzer),
 		},
 	}
 }
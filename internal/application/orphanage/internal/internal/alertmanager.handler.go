@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AlertmanagerHandler handles finding references to Secrets and ConfigMaps in Alertmanager resources
+type AlertmanagerHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewAlertmanagerHandler creates a new AlertmanagerHandler
+func NewAlertmanagerHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *AlertmanagerHandler {
+	return &AlertmanagerHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by Alertmanagers in the namespace to the graph
+func (h *AlertmanagerHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Alertmanager", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *AlertmanagerHandler) GetResourceType() string {
+	return "Alertmanager"
+}
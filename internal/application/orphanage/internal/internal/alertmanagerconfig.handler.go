@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AlertmanagerConfigHandler handles finding references to Secrets and ConfigMaps in AlertmanagerConfig resources
+type AlertmanagerConfigHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewAlertmanagerConfigHandler creates a new AlertmanagerConfigHandler
+func NewAlertmanagerConfigHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *AlertmanagerConfigHandler {
+	return &AlertmanagerConfigHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by AlertmanagerConfigs in the namespace to the graph
+func (h *AlertmanagerConfigHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "AlertmanagerConfig", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *AlertmanagerConfigHandler) GetResourceType() string {
+	return "AlertmanagerConfig"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ArgoCDHandler handles finding the Secrets consumed by the Argo CD instance of a namespace
+type ArgoCDHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewArgoCDHandler creates a new ArgoCDHandler
+func NewArgoCDHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ArgoCDHandler {
+	return &ArgoCDHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret consumed by the Argo CD instance in the namespace to the graph
+func (h *ArgoCDHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ArgoCD", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ArgoCDHandler) GetResourceType() string {
+	return "ArgoCD"
+}
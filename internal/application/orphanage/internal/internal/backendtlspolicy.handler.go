@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BackendTLSPolicyHandler handles finding references to Secrets and ConfigMaps in BackendTLSPolicy resources
+type BackendTLSPolicyHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewBackendTLSPolicyHandler creates a new BackendTLSPolicyHandler
+func NewBackendTLSPolicyHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *BackendTLSPolicyHandler {
+	return &BackendTLSPolicyHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by BackendTLSPolicies in the namespace to the graph
+func (h *BackendTLSPolicyHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "BackendTLSPolicy", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *BackendTLSPolicyHandler) GetResourceType() string {
+	return "BackendTLSPolicy"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertificateHandler handles finding references to Secrets in Certificate resources
+type CertificateHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewCertificateHandler creates a new CertificateHandler
+func NewCertificateHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *CertificateHandler {
+	return &CertificateHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by Certificates in the namespace to the graph
+func (h *CertificateHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Certificate", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *CertificateHandler) GetResourceType() string {
+	return "Certificate"
+}
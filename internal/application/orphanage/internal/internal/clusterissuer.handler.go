@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterIssuerHandler handles finding references to Secrets in ClusterIssuer resources
+type ClusterIssuerHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewClusterIssuerHandler creates a new ClusterIssuerHandler
+func NewClusterIssuerHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ClusterIssuerHandler {
+	return &ClusterIssuerHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by ClusterIssuers in the cluster to the graph
+func (h *ClusterIssuerHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ClusterIssuer", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ClusterIssuerHandler) GetResourceType() string {
+	return "ClusterIssuer"
+}
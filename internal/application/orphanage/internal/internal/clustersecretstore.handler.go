@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ClusterSecretStoreHandler handles finding references to Secrets in ClusterSecretStore resources
+type ClusterSecretStoreHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewClusterSecretStoreHandler creates a new ClusterSecretStoreHandler
+func NewClusterSecretStoreHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ClusterSecretStoreHandler {
+	return &ClusterSecretStoreHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by ClusterSecretStores in the cluster to the graph
+func (h *ClusterSecretStoreHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ClusterSecretStore", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ClusterSecretStoreHandler) GetResourceType() string {
+	return "ClusterSecretStore"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ControllerRevisionHandler handles finding references to Secrets and ConfigMaps in ControllerRevision resources
+type ControllerRevisionHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewControllerRevisionHandler creates a new ControllerRevisionHandler
+func NewControllerRevisionHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ControllerRevisionHandler {
+	return &ControllerRevisionHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by ControllerRevisions in the namespace to the graph
+func (h *ControllerRevisionHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ControllerRevision", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ControllerRevisionHandler) GetResourceType() string {
+	return "ControllerRevision"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CronJobHandler handles finding references to Secrets and ConfigMaps in CronJob resources
+type CronJobHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewCronJobHandler creates a new CronJobHandler
+func NewCronJobHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *CronJobHandler {
+	return &CronJobHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by CronJobs in the namespace to the graph
+func (h *CronJobHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "CronJob", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *CronJobHandler) GetResourceType() string {
+	return "CronJob"
+}
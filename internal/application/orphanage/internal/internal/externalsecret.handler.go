@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ExternalSecretHandler handles finding references to Secrets and ConfigMaps in ExternalSecret resources
+type ExternalSecretHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewExternalSecretHandler creates a new ExternalSecretHandler
+func NewExternalSecretHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ExternalSecretHandler {
+	return &ExternalSecretHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced or generated by ExternalSecrets in the namespace to the graph
+func (h *ExternalSecretHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ExternalSecret", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ExternalSecretHandler) GetResourceType() string {
+	return "ExternalSecret"
+}
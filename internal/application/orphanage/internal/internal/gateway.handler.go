@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GatewayHandler handles finding references to Secrets and ConfigMaps in Gateway resources
+type GatewayHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewGatewayHandler creates a new GatewayHandler
+func NewGatewayHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *GatewayHandler {
+	return &GatewayHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by Gateways in the cluster to the graph
+func (h *GatewayHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Gateway", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *GatewayHandler) GetResourceType() string {
+	return "Gateway"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GitRepositoryHandler handles finding references to Secrets in GitRepository resources
+type GitRepositoryHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewGitRepositoryHandler creates a new GitRepositoryHandler
+func NewGitRepositoryHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *GitRepositoryHandler {
+	return &GitRepositoryHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by GitRepositories in the namespace to the graph
+func (h *GitRepositoryHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "GitRepository", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *GitRepositoryHandler) GetResourceType() string {
+	return "GitRepository"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HelmReleaseHandler handles finding references to Secrets and ConfigMaps in HelmRelease resources
+type HelmReleaseHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewHelmReleaseHandler creates a new HelmReleaseHandler
+func NewHelmReleaseHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *HelmReleaseHandler {
+	return &HelmReleaseHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by HelmReleases in the namespace to the graph
+func (h *HelmReleaseHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "HelmRelease", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *HelmReleaseHandler) GetResourceType() string {
+	return "HelmRelease"
+}
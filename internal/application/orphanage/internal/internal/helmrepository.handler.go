@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HelmRepositoryHandler handles finding references to Secrets in HelmRepository resources
+type HelmRepositoryHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewHelmRepositoryHandler creates a new HelmRepositoryHandler
+func NewHelmRepositoryHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *HelmRepositoryHandler {
+	return &HelmRepositoryHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by HelmRepositories in the namespace to the graph
+func (h *HelmRepositoryHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "HelmRepository", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *HelmRepositoryHandler) GetResourceType() string {
+	return "HelmRepository"
+}
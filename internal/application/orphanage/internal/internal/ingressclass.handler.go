@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IngressClassHandler handles finding references to Secrets and ConfigMaps in IngressClass resources
+type IngressClassHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewIngressClassHandler creates a new IngressClassHandler
+func NewIngressClassHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *IngressClassHandler {
+	return &IngressClassHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by IngressClasses in the cluster to the graph
+func (h *IngressClassHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "IngressClass", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *IngressClassHandler) GetResourceType() string {
+	return "IngressClass"
+}
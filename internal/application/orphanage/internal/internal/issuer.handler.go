@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IssuerHandler handles finding references to Secrets in Issuer resources
+type IssuerHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewIssuerHandler creates a new IssuerHandler
+func NewIssuerHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *IssuerHandler {
+	return &IssuerHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by Issuers in the namespace to the graph
+func (h *IssuerHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Issuer", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *IssuerHandler) GetResourceType() string {
+	return "Issuer"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// JobHandler handles finding references to Secrets and ConfigMaps in Job resources
+type JobHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewJobHandler creates a new JobHandler
+func NewJobHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *JobHandler {
+	return &JobHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by Jobs in the namespace to the graph
+func (h *JobHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Job", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *JobHandler) GetResourceType() string {
+	return "Job"
+}
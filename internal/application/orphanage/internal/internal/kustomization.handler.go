@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KustomizationHandler handles finding references to Secrets and ConfigMaps in Kustomization resources
+type KustomizationHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewKustomizationHandler creates a new KustomizationHandler
+func NewKustomizationHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *KustomizationHandler {
+	return &KustomizationHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by Kustomizations in the namespace to the graph
+func (h *KustomizationHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Kustomization", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *KustomizationHandler) GetResourceType() string {
+	return "Kustomization"
+}
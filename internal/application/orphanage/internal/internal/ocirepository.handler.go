@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OCIRepositoryHandler handles finding references to Secrets in OCIRepository resources
+type OCIRepositoryHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewOCIRepositoryHandler creates a new OCIRepositoryHandler
+func NewOCIRepositoryHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *OCIRepositoryHandler {
+	return &OCIRepositoryHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by OCIRepositories in the namespace to the graph
+func (h *OCIRepositoryHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "OCIRepository", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *OCIRepositoryHandler) GetResourceType() string {
+	return "OCIRepository"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PersistentVolumeHandler handles finding references to Secrets and ConfigMaps in PersistentVolume resources
+type PersistentVolumeHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewPersistentVolumeHandler creates a new PersistentVolumeHandler
+func NewPersistentVolumeHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *PersistentVolumeHandler {
+	return &PersistentVolumeHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by PersistentVolumes in the cluster to the graph
+func (h *PersistentVolumeHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "PersistentVolume", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *PersistentVolumeHandler) GetResourceType() string {
+	return "PersistentVolume"
+}
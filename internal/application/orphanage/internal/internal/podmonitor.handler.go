@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodMonitorHandler handles finding references to Secrets and ConfigMaps in PodMonitor resources
+type PodMonitorHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewPodMonitorHandler creates a new PodMonitorHandler
+func NewPodMonitorHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *PodMonitorHandler {
+	return &PodMonitorHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by PodMonitors in the namespace to the graph
+func (h *PodMonitorHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "PodMonitor", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *PodMonitorHandler) GetResourceType() string {
+	return "PodMonitor"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodTemplateHandler handles finding references to Secrets and ConfigMaps in PodTemplate resources
+type PodTemplateHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewPodTemplateHandler creates a new PodTemplateHandler
+func NewPodTemplateHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *PodTemplateHandler {
+	return &PodTemplateHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by PodTemplates in the namespace to the graph
+func (h *PodTemplateHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "PodTemplate", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *PodTemplateHandler) GetResourceType() string {
+	return "PodTemplate"
+}
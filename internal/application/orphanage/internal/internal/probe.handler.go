@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ProbeHandler handles finding references to Secrets and ConfigMaps in Probe resources
+type ProbeHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewProbeHandler creates a new ProbeHandler
+func NewProbeHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ProbeHandler {
+	return &ProbeHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by Probes in the namespace to the graph
+func (h *ProbeHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Probe", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ProbeHandler) GetResourceType() string {
+	return "Probe"
+}
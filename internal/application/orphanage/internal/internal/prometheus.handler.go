@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PrometheusHandler handles finding references to Secrets and ConfigMaps in Prometheus resources
+type PrometheusHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewPrometheusHandler creates a new PrometheusHandler
+func NewPrometheusHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *PrometheusHandler {
+	return &PrometheusHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by Prometheuses in the namespace to the graph
+func (h *PrometheusHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "Prometheus", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *PrometheusHandler) GetResourceType() string {
+	return "Prometheus"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PushSecretHandler handles finding references to Secrets in PushSecret resources
+type PushSecretHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewPushSecretHandler creates a new PushSecretHandler
+func NewPushSecretHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *PushSecretHandler {
+	return &PushSecretHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by PushSecrets in the namespace to the graph
+func (h *PushSecretHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "PushSecret", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *PushSecretHandler) GetResourceType() string {
+	return "PushSecret"
+}
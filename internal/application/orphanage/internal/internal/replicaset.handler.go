@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReplicaSetHandler handles finding references to Secrets and ConfigMaps in ReplicaSet resources
+type ReplicaSetHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewReplicaSetHandler creates a new ReplicaSetHandler
+func NewReplicaSetHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ReplicaSetHandler {
+	return &ReplicaSetHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by ReplicaSets in the namespace to the graph
+func (h *ReplicaSetHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ReplicaSet", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ReplicaSetHandler) GetResourceType() string {
+	return "ReplicaSet"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReplicationControllerHandler handles finding references to Secrets and ConfigMaps in ReplicationController resources
+type ReplicationControllerHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewReplicationControllerHandler creates a new ReplicationControllerHandler
+func NewReplicationControllerHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ReplicationControllerHandler {
+	return &ReplicationControllerHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by ReplicationControllers in the namespace to the graph
+func (h *ReplicationControllerHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ReplicationController", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ReplicationControllerHandler) GetResourceType() string {
+	return "ReplicationController"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretStoreHandler handles finding references to Secrets in SecretStore resources
+type SecretStoreHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewSecretStoreHandler creates a new SecretStoreHandler
+func NewSecretStoreHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *SecretStoreHandler {
+	return &SecretStoreHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by SecretStores in the namespace to the graph
+func (h *SecretStoreHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "SecretStore", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *SecretStoreHandler) GetResourceType() string {
+	return "SecretStore"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ServiceMonitorHandler handles finding references to Secrets and ConfigMaps in ServiceMonitor resources
+type ServiceMonitorHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewServiceMonitorHandler creates a new ServiceMonitorHandler
+func NewServiceMonitorHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *ServiceMonitorHandler {
+	return &ServiceMonitorHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by ServiceMonitors in the namespace to the graph
+func (h *ServiceMonitorHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "ServiceMonitor", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *ServiceMonitorHandler) GetResourceType() string {
+	return "ServiceMonitor"
+}
@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StorageClassHandler handles finding references to Secrets and ConfigMaps in StorageClass resources
+type StorageClassHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewStorageClassHandler creates a new StorageClassHandler
+func NewStorageClassHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *StorageClassHandler {
+	return &StorageClassHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by StorageClasses in the cluster to the graph
+func (h *StorageClassHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "StorageClass", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *StorageClassHandler) GetResourceType() string {
+	return "StorageClass"
+}
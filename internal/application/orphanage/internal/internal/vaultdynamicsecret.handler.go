@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VaultDynamicSecretHandler handles finding the Secrets generated by VaultDynamicSecret resources
+type VaultDynamicSecretHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewVaultDynamicSecretHandler creates a new VaultDynamicSecretHandler
+func NewVaultDynamicSecretHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *VaultDynamicSecretHandler {
+	return &VaultDynamicSecretHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret generated by VaultDynamicSecrets in the namespace to the graph
+func (h *VaultDynamicSecretHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "VaultDynamicSecret", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *VaultDynamicSecretHandler) GetResourceType() string {
+	return "VaultDynamicSecret"
+}
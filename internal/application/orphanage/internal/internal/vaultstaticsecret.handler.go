@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VaultStaticSecretHandler handles finding the Secrets generated by VaultStaticSecret resources
+type VaultStaticSecretHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewVaultStaticSecretHandler creates a new VaultStaticSecretHandler
+func NewVaultStaticSecretHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *VaultStaticSecretHandler {
+	return &VaultStaticSecretHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret generated by VaultStaticSecrets in the namespace to the graph
+func (h *VaultStaticSecretHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "VaultStaticSecret", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *VaultStaticSecretHandler) GetResourceType() string {
+	return "VaultStaticSecret"
+}
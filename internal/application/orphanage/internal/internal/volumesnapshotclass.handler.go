@@ -0,0 +1,33 @@
+package resourceHandler
+
+import (
+	"context"
+
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VolumeSnapshotClassHandler handles finding references to Secrets and ConfigMaps in VolumeSnapshotClass resources
+type VolumeSnapshotClassHandler struct {
+	client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+}
+
+// NewVolumeSnapshotClassHandler creates a new VolumeSnapshotClassHandler
+func NewVolumeSnapshotClassHandler(c client.Client, analyzer *core.ReferenceAnalyzer) *VolumeSnapshotClassHandler {
+	return &VolumeSnapshotClassHandler{
+		Client:            c,
+		referenceAnalyzer: analyzer,
+	}
+}
+
+// CollectReferences adds every Secret referenced by VolumeSnapshotClasses in the cluster to the graph
+func (h *VolumeSnapshotClassHandler) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	return h.referenceAnalyzer.CollectReferences(ctx, namespace, "VolumeSnapshotClass", g)
+}
+
+// GetResourceType returns the resource type this handler processes
+func (h *VolumeSnapshotClassHandler) GetResourceType() string {
+	return "VolumeSnapshotClass"
+}
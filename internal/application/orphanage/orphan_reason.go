@@ -0,0 +1,76 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReasonUnreferenced is used for orphans that no resource references
+	ReasonUnreferenced = "Unreferenced"
+	// ReasonServiceAccountDeleted is used for service account token Secrets whose ServiceAccount no longer exists
+	ReasonServiceAccountDeleted = "ServiceAccountDeleted"
+	// ReasonServiceAccountRecreated is used for service account token Secrets whose ServiceAccount was deleted and
+	// created again under the same name, so the token was issued for a ServiceAccount that no longer exists
+	ReasonServiceAccountRecreated = "ServiceAccountRecreated"
+	// ReasonCertificateDeleted is used for Secrets written by a cert-manager Certificate that no longer exists
+	ReasonCertificateDeleted = "CertificateDeleted"
+	// ReasonGeneratedSecretUnreferenced is used for resources that generate a Secret no resource references
+	ReasonGeneratedSecretUnreferenced = "GeneratedSecretUnreferenced"
+)
+
+// generatorKinds are the kinds reported in place of the unreferenced Secrets they generate
+var generatorKinds = []string{"ExternalSecret", "VaultStaticSecret", "VaultDynamicSecret"}
+
+// certificateNameAnnotation is set by cert-manager on the Secrets written for a Certificate
+const certificateNameAnnotation = "cert-manager.io/certificate-name"
+
+// ExplainOrphan returns a machine-readable reason and a human-readable message explaining why
+// an unreferenced resource is an orphan
+func ExplainOrphan(ctx context.Context, c client.Reader, resource client.Object) (reason, message string, err error) {
+	if kind := resource.GetObjectKind().GroupVersionKind().Kind; slices.Contains(generatorKinds, kind) {
+		return ReasonGeneratedSecretUnreferenced, fmt.Sprintf("No resource references the Secret generated by this %s, which recreates the Secret if it is deleted", kind), nil
+	}
+
+	if secret, ok := resource.(*corev1.Secret); ok && secret.Type == corev1.SecretTypeServiceAccountToken {
+		if name := secret.Annotations[corev1.ServiceAccountNameKey]; name != "" {
+			return explainServiceAccountToken(ctx, c, secret, name)
+		}
+	}
+
+	// Secrets of an existing Certificate are referenced by it, so an unreferenced one has lost its Certificate
+	if name := resource.GetAnnotations()[certificateNameAnnotation]; name != "" {
+		return ReasonCertificateDeleted, fmt.Sprintf("Managed by Certificate %s, which no longer exists or no longer writes to this Secret", name), nil
+	}
+
+	return explainUnreferenced(resource)
+}
+
+// explainServiceAccountToken explains why a service account token Secret is orphaned. The ServiceAccount
+// only references its token while its UID matches the kubernetes.io/service-account.uid annotation, so a
+// ServiceAccount of the same name that exists was created after the token.
+func explainServiceAccountToken(ctx context.Context, c client.Reader, secret *corev1.Secret, name string) (reason, message string, err error) {
+	serviceAccount := &corev1.ServiceAccount{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: secret.Namespace, Name: name}, serviceAccount); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ReasonServiceAccountDeleted, fmt.Sprintf("ServiceAccount %s that issued this token no longer exists", name), nil
+		}
+		return "", "", err
+	}
+
+	if uid := secret.Annotations[corev1.ServiceAccountUIDKey]; uid != "" && uid != string(serviceAccount.UID) {
+		return ReasonServiceAccountRecreated, fmt.Sprintf("ServiceAccount %s that issued this token was recreated, the token belongs to the deleted ServiceAccount with UID %s", name, uid), nil
+	}
+
+	return explainUnreferenced(secret)
+}
+
+// explainUnreferenced explains an orphan that no resource references
+func explainUnreferenced(resource client.Object) (reason, message string, err error) {
+	return ReasonUnreferenced, fmt.Sprintf("No resource references this %s", resource.GetObjectKind().GroupVersionKind().Kind), nil
+}
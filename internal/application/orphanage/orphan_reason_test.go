@@ -0,0 +1,58 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExplainOrphanServiceAccountToken(t *testing.T) {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "builder", Namespace: "default", UID: types.UID("new-uid")},
+	}
+
+	tests := []struct {
+		name           string
+		serviceAccount *corev1.ServiceAccount
+		tokenUID       string
+		expectedReason string
+	}{
+		{name: "ServiceAccount deleted", tokenUID: "old-uid", expectedReason: ReasonServiceAccountDeleted},
+		{name: "ServiceAccount recreated", serviceAccount: serviceAccount, tokenUID: "old-uid", expectedReason: ReasonServiceAccountRecreated},
+		{name: "ServiceAccount with matching UID", serviceAccount: serviceAccount, tokenUID: "new-uid", expectedReason: ReasonUnreferenced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "builder-token",
+					Namespace: "default",
+					Annotations: map[string]string{
+						corev1.ServiceAccountNameKey: "builder",
+						corev1.ServiceAccountUIDKey:  tt.tokenUID,
+					},
+				},
+				Type: corev1.SecretTypeServiceAccountToken,
+			}
+			token.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+
+			builder := fake.NewClientBuilder()
+			if tt.serviceAccount != nil {
+				builder = builder.WithObjects(tt.serviceAccount)
+			}
+
+			reason, _, err := ExplainOrphan(context.Background(), builder.Build(), token)
+			if err != nil {
+				t.Fatalf("ExplainOrphan returned an error: %v", err)
+			}
+			if reason != tt.expectedReason {
+				t.Errorf("expected reason %s, got %s", tt.expectedReason, reason)
+			}
+		})
+	}
+}
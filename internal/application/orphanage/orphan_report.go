@@ -0,0 +1,79 @@
+package application
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OrphanKey identifies an orphaned resource across scans.
+// The UID makes a recreated resource with the same name start over as a new orphan.
+type OrphanKey struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       types.UID
+}
+
+// KeyOf returns the OrphanKey of a resource
+func KeyOf(resource client.Object) OrphanKey {
+	return OrphanKey{
+		Kind:      resource.GetObjectKind().GroupVersionKind().Kind,
+		Namespace: resource.GetNamespace(),
+		Name:      resource.GetName(),
+		UID:       resource.GetUID(),
+	}
+}
+
+// TrackedOrphan is an orphaned resource together with the time it was first seen orphaned
+type TrackedOrphan struct {
+	client.Object
+	// FirstSeenOrphaned is the time of the first scan that found the resource unreferenced
+	FirstSeenOrphaned time.Time
+}
+
+// OrphanReport is the outcome of a scan once orphans have been tracked across scans
+type OrphanReport struct {
+	// Orphans are the resources that have been unreferenced for at least the grace period
+	Orphans []TrackedOrphan
+	// Pending are the unreferenced resources that are still held back by the grace period
+	Pending []TrackedOrphan
+	// Excluded are the unreferenced resources skipped because they matched an exclusion rule
+	Excluded []client.Object
+	// RollbackOnly are the resources only referenced by revision history
+	RollbackOnly []client.Object
+	// RequeueAfter is the time until the next pending orphan leaves the grace period, or zero if none is pending
+	RequeueAfter time.Duration
+}
+
+// NewOrphanReport tracks the orphans of a scan result. Resources that were already orphaned in an
+// earlier scan keep their first-seen time from firstSeen; all others are first seen now.
+// Orphans are held back as pending until they have been unreferenced for the grace period.
+func NewOrphanReport(result ScanResult, firstSeen map[OrphanKey]time.Time, gracePeriod time.Duration, now time.Time) OrphanReport {
+	report := OrphanReport{
+		Excluded:     result.Excluded,
+		RollbackOnly: result.RollbackOnly,
+	}
+
+	for _, orphan := range result.Orphans {
+		seen, exists := firstSeen[KeyOf(orphan)]
+		if !exists || seen.After(now) {
+			seen = now
+		}
+
+		tracked := TrackedOrphan{Object: orphan, FirstSeenOrphaned: seen}
+		remaining := gracePeriod - now.Sub(seen)
+		if remaining <= 0 {
+			report.Orphans = append(report.Orphans, tracked)
+			continue
+		}
+
+		report.Pending = append(report.Pending, tracked)
+		if report.RequeueAfter == 0 || remaining < report.RequeueAfter {
+			report.RequeueAfter = remaining
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,129 @@
+package application
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNewOrphanReport(t *testing.T) {
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+		}
+	}
+	old, recent, fresh := secret("old"), secret("recent"), secret("fresh")
+
+	tests := []struct {
+		name                 string
+		orphans              []client.Object
+		firstSeen            map[OrphanKey]time.Time
+		gracePeriod          time.Duration
+		expectedOrphans      []string
+		expectedPending      []string
+		expectedRequeueAfter time.Duration
+		expectedFirstSeen    map[string]time.Time
+	}{
+		{
+			name:              "no grace period reports every orphan at once",
+			orphans:           []client.Object{fresh},
+			expectedOrphans:   []string{"fresh"},
+			expectedFirstSeen: map[string]time.Time{"fresh": now},
+		},
+		{
+			name:                 "new orphan is pending for the whole grace period",
+			orphans:              []client.Object{fresh},
+			gracePeriod:          time.Hour,
+			expectedPending:      []string{"fresh"},
+			expectedRequeueAfter: time.Hour,
+			expectedFirstSeen:    map[string]time.Time{"fresh": now},
+		},
+		{
+			name:    "orphan leaves the grace period once it has elapsed",
+			orphans: []client.Object{old},
+			firstSeen: map[OrphanKey]time.Time{
+				KeyOf(old): now.Add(-time.Hour),
+			},
+			gracePeriod:       time.Hour,
+			expectedOrphans:   []string{"old"},
+			expectedFirstSeen: map[string]time.Time{"old": now.Add(-time.Hour)},
+		},
+		{
+			name:    "requeue after the first pending orphan leaves the grace period",
+			orphans: []client.Object{old, recent, fresh},
+			firstSeen: map[OrphanKey]time.Time{
+				KeyOf(old):    now.Add(-2 * time.Hour),
+				KeyOf(recent): now.Add(-45 * time.Minute),
+			},
+			gracePeriod:          time.Hour,
+			expectedOrphans:      []string{"old"},
+			expectedPending:      []string{"recent", "fresh"},
+			expectedRequeueAfter: 15 * time.Minute,
+			expectedFirstSeen: map[string]time.Time{
+				"old":    now.Add(-2 * time.Hour),
+				"recent": now.Add(-45 * time.Minute),
+				"fresh":  now,
+			},
+		},
+		{
+			name:    "first-seen time in the future starts over",
+			orphans: []client.Object{recent},
+			firstSeen: map[OrphanKey]time.Time{
+				KeyOf(recent): now.Add(time.Hour),
+			},
+			gracePeriod:          time.Hour,
+			expectedPending:      []string{"recent"},
+			expectedRequeueAfter: time.Hour,
+			expectedFirstSeen:    map[string]time.Time{"recent": now},
+		},
+		{
+			name:    "recreated resource starts over",
+			orphans: []client.Object{old},
+			firstSeen: map[OrphanKey]time.Time{
+				{Kind: "Secret", Namespace: "default", Name: "old", UID: types.UID("deleted")}: now.Add(-2 * time.Hour),
+			},
+			gracePeriod:          time.Hour,
+			expectedPending:      []string{"old"},
+			expectedRequeueAfter: time.Hour,
+			expectedFirstSeen:    map[string]time.Time{"old": now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewOrphanReport(ScanResult{Orphans: tt.orphans}, tt.firstSeen, tt.gracePeriod, now)
+
+			firstSeen := map[string]time.Time{}
+			names := func(orphans []TrackedOrphan) []string {
+				var result []string
+				for _, orphan := range orphans {
+					result = append(result, orphan.GetName())
+					firstSeen[orphan.GetName()] = orphan.FirstSeenOrphaned
+				}
+				return result
+			}
+
+			if orphans := names(report.Orphans); !slices.Equal(orphans, tt.expectedOrphans) {
+				t.Errorf("expected orphans %v, got %v", tt.expectedOrphans, orphans)
+			}
+			if pending := names(report.Pending); !slices.Equal(pending, tt.expectedPending) {
+				t.Errorf("expected pending %v, got %v", tt.expectedPending, pending)
+			}
+			if report.RequeueAfter != tt.expectedRequeueAfter {
+				t.Errorf("expected RequeueAfter %v, got %v", tt.expectedRequeueAfter, report.RequeueAfter)
+			}
+			for name, expected := range tt.expectedFirstSeen {
+				if !firstSeen[name].Equal(expected) {
+					t.Errorf("expected %s first seen at %v, got %v", name, expected, firstSeen[name])
+				}
+			}
+		})
+	}
+}
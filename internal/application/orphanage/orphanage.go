@@ -3,117 +3,305 @@ package application
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	handlerRegistry "github.com/toKrzysztof/kponos/internal/application/orphanage/internal"
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// OrphanFinder is a function that finds orphaned resources of a specific type
-type OrphanFinder func(context.Context, string) ([]client.Object, error)
+// ResourceScan describes which resources of a single type are scanned for orphans
+type ResourceScan struct {
+	// ResourceType is the kind of resource to scan (e.g. "Secret", "ConfigMap")
+	ResourceType string
+	// Selector restricts the scan to resources with matching labels; nil matches everything
+	Selector labels.Selector
+	// ExcludeNames lists names of resources that are never reported as orphans
+	ExcludeNames []string
+	// MinAge is the minimum age a resource must reach before it can be reported as an orphan
+	MinAge time.Duration
+	// Exclusions describes resources that are never reported as orphans
+	Exclusions Exclusions
+}
+
+// ScanResult holds the outcome of scanning a namespace for orphans
+type ScanResult struct {
+	// Orphans are the resources that are not referenced by any other resources
+	Orphans []client.Object
+	// Excluded are the unreferenced resources skipped because they matched an exclusion rule
+	Excluded []client.Object
+	// RollbackOnly are the resources only referenced by revision history, such as old ReplicaSets of a
+	// Deployment. They are not in use, but rolling back would need them.
+	RollbackOnly []client.Object
+}
+
+// clusterWideResourceTypes are the referencing resource types whose references are collected across the
+// cluster whatever the scanned namespace, so they are walked once for a scan of several namespaces
+var clusterWideResourceTypes = []string{
+	"ClusterIssuer",
+	"ClusterSecretStore",
+	"Gateway",
+	"Ingress",
+	"IngressClass",
+	"PersistentVolume",
+	"StorageClass",
+	"VolumeSnapshotClass",
+}
+
+// ResourceLister is a function that lists the resources of a specific type in a namespace
+type ResourceLister func(context.Context, ...client.ListOption) ([]client.Object, error)
+
+// referencingResourceTypes are the resource types that are walked to build the reference graph
+var referencingResourceTypes = []string{
+	"Alertmanager",
+	"AlertmanagerConfig",
+	"ArgoCD",
+	"BackendTLSPolicy",
+	"Certificate",
+	"ClusterIssuer",
+	"ClusterSecretStore",
+	"ControllerRevision",
+	"CronJob",
+	"DaemonSet",
+	"Deployment",
+	"ExternalSecret",
+	"Gateway",
+	"GitRepository",
+	"HelmRelease",
+	"HelmRepository",
+	"Ingress",
+	"IngressClass",
+	"Issuer",
+	"Job",
+	"Kustomization",
+	"OCIRepository",
+	"PersistentVolume",
+	"Pod",
+	"PodMonitor",
+	"PodTemplate",
+	"Probe",
+	"Prometheus",
+	"PushSecret",
+	"ReplicaSet",
+	"ReplicationController",
+	"SecretStore",
+	"ServiceAccount",
+	"ServiceMonitor",
+	"StatefulSet",
+	"StorageClass",
+	"VaultDynamicSecret",
+	"VaultStaticSecret",
+	"VolumeSnapshotClass",
+}
 
 // Orphanage handles finding orphaned resources in a namespace
 type Orphanage struct {
-	client          client.Client
-	handlerRegistry *handlerRegistry.HandlerRegistry
-	finders         map[string]OrphanFinder
+	client            client.Client
+	referenceAnalyzer *core.ReferenceAnalyzer
+	handlerRegistry   *handlerRegistry.HandlerRegistry
+	listers           map[string]ResourceLister
 }
 
-// NewOrphanage creates a new Orphanage instance
-func NewOrphanage(c client.Client) *Orphanage {
+// NewOrphanage creates a new Orphanage instance whose reference finders are configured with the given options
+func NewOrphanage(c client.Client, opts core.Options) *Orphanage {
+	referenceAnalyzer := core.NewReferenceAnalyzer(c, opts)
 	o := &Orphanage{
-		client:          c,
-		handlerRegistry: handlerRegistry.NewHandlerRegistry(c),
+		client:            c,
+		referenceAnalyzer: referenceAnalyzer,
+		handlerRegistry:   handlerRegistry.NewHandlerRegistry(c, referenceAnalyzer),
 	}
 
-	o.finders = map[string]OrphanFinder{
-		"Secret":    o.findOrphanedSecrets,
-		"ConfigMap": o.findOrphanedConfigMaps,
+	o.listers = map[string]ResourceLister{
+		"Secret":    o.listSecrets,
+		"ConfigMap": o.listConfigMaps,
 	}
 
 	return o
 }
 
-// FindOrphans finds all orphaned Secrets and ConfigMaps in a namespace.
-// An orphan is a Secret or ConfigMap that is not referenced by any other resources.
-func (o *Orphanage) FindOrphans(ctx context.Context, resourceType string, namespace string) ([]client.Object, error) {
-	finder, exists := o.finders[resourceType]
-	if !exists {
-		return nil, fmt.Errorf("unsupported resource type: %s", resourceType)
+// FindOrphans finds all orphaned resources described by the scans in a namespace.
+// An orphan is a Secret or ConfigMap that is not referenced by any other resources. An unreferenced
+// Secret generated by another resource, such as an ExternalSecret, is reported as that resource instead.
+// Unreferenced resources matching an exclusion rule are reported separately; referenced ones are not reported.
+// The referencing resources are listed once per call and shared by all scans.
+func (o *Orphanage) FindOrphans(ctx context.Context, namespace string, scans []ResourceScan) (ScanResult, error) {
+	results, err := o.FindOrphansInNamespaces(ctx, []string{namespace}, scans)
+	if err != nil {
+		return ScanResult{}, err
+	}
+	return results[namespace], nil
+}
+
+// FindOrphansInNamespaces finds the orphaned resources described by the scans in each of the namespaces,
+// like FindOrphans. The references of all namespaces are collected into one graph, so resources listed
+// across the cluster, such as Gateways, are listed once rather than once per namespace.
+func (o *Orphanage) FindOrphansInNamespaces(ctx context.Context, namespaces []string, scans []ResourceScan) (map[string]ScanResult, error) {
+	listers := make([]ResourceLister, 0, len(scans))
+	for _, scan := range scans {
+		lister, exists := o.listers[scan.ResourceType]
+		if !exists {
+			return nil, fmt.Errorf("unsupported resource type: %s", scan.ResourceType)
+		}
+		listers = append(listers, lister)
+	}
+
+	referenceGraph, err := o.buildReferenceGraph(ctx, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]ScanResult, len(namespaces))
+	now := time.Now()
+	for _, namespace := range namespaces {
+		result, err := o.scanNamespace(ctx, namespace, scans, listers, referenceGraph, now)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", namespace, err)
+		}
+		results[namespace] = result
+	}
+
+	return results, nil
+}
+
+// scanNamespace lists the resources of every scan in the namespace and sorts the unreferenced ones
+// into orphans, excluded and rollback-only resources
+func (o *Orphanage) scanNamespace(ctx context.Context, namespace string, scans []ResourceScan, listers []ResourceLister, referenceGraph *graph.ReferenceGraph, now time.Time) (ScanResult, error) {
+	var result ScanResult
+	for i, lister := range listers {
+		scan := scans[i]
+
+		resources, err := lister(ctx, listOptions(namespace, scan)...)
+		if err != nil {
+			return ScanResult{}, err
+		}
+
+		for _, resource := range resources {
+			switch {
+			case !o.isOrphaned(referenceGraph, scan.ResourceType, resource):
+				continue
+			case isExcluded(resource, scan):
+				result.Excluded = append(result.Excluded, resource)
+			case referenceGraph.IsReferencedByHistory(scan.ResourceType, resource.GetNamespace(), resource.GetName()):
+				result.RollbackOnly = append(result.RollbackOnly, resource)
+			case isOldEnough(resource, scan, now):
+				result.Orphans = appendOrphan(result.Orphans, orphanOf(referenceGraph, scan.ResourceType, resource))
+			}
+		}
 	}
 
-	return finder(ctx, namespace)
+	return result, nil
 }
 
-// findOrphanedSecrets finds all orphaned Secrets in the given namespace
-func (o *Orphanage) findOrphanedSecrets(ctx context.Context, namespace string) ([]client.Object, error) {
-	var orphanedSecrets []client.Object
+// ReferencedNamespaces returns the namespaces other than its own whose orphans can change with an object
+func (o *Orphanage) ReferencedNamespaces(ctx context.Context, obj client.Object) ([]string, error) {
+	return o.referenceAnalyzer.ReferencedNamespaces(ctx, obj)
+}
+
+// SupportedResourceTypes returns the resource types that can be scanned for orphans
+func (o *Orphanage) SupportedResourceTypes() []string {
+	return []string{"Secret", "ConfigMap"}
+}
 
+// buildReferenceGraph walks every referencing resource type in the namespaces once and records the
+// Secrets and ConfigMaps they reference. Cluster-wide resource types are walked once for all namespaces.
+func (o *Orphanage) buildReferenceGraph(ctx context.Context, namespaces []string) (*graph.ReferenceGraph, error) {
+	referenceGraph := graph.NewReferenceGraph()
+
+	for _, resourceType := range referencingResourceTypes {
+		handler := o.handlerRegistry.GetHandler(resourceType)
+		if handler == nil {
+			return nil, fmt.Errorf("no handler found for resource type: %s", resourceType)
+		}
+
+		walked := namespaces
+		if slices.Contains(clusterWideResourceTypes, resourceType) && len(namespaces) > 0 {
+			walked = namespaces[:1]
+		}
+		for _, namespace := range walked {
+			if err := handler.CollectReferences(ctx, o.client, namespace, referenceGraph); err != nil {
+				return nil, fmt.Errorf("error collecting references from %s: %w", resourceType, err)
+			}
+		}
+	}
+
+	return referenceGraph, nil
+}
+
+// listSecrets lists the Secrets matching the given options.
+// The kind is set explicitly because typed lists do not always carry it.
+func (o *Orphanage) listSecrets(ctx context.Context, opts ...client.ListOption) ([]client.Object, error) {
 	secretList := &corev1.SecretList{}
-	if err := o.client.List(ctx, secretList, client.InNamespace(namespace)); err != nil {
+	if err := o.client.List(ctx, secretList, opts...); err != nil {
 		return nil, fmt.Errorf("unable to list Secrets: %w", err)
 	}
 
+	secrets := make([]client.Object, len(secretList.Items))
 	for i := range secretList.Items {
-		secret := &secretList.Items[i]
-		if isOrphaned, err := o.isOrphaned(ctx, secret, namespace); err != nil {
-			return nil, fmt.Errorf("error checking if Secret %s is orphaned: %w", secret.Name, err)
-		} else if isOrphaned {
-			orphanedSecrets = append(orphanedSecrets, secret)
-		}
+		secretList.Items[i].SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+		secrets[i] = &secretList.Items[i]
 	}
 
-	return orphanedSecrets, nil
+	return secrets, nil
 }
 
-// findOrphanedConfigMaps finds all orphaned ConfigMaps in the given namespace
-func (o *Orphanage) findOrphanedConfigMaps(ctx context.Context, namespace string) ([]client.Object, error) {
-	var orphanedConfigMaps []client.Object
-
+// listConfigMaps lists the ConfigMaps matching the given options.
+// The kind is set explicitly because typed lists do not always carry it.
+func (o *Orphanage) listConfigMaps(ctx context.Context, opts ...client.ListOption) ([]client.Object, error) {
 	configMapList := &corev1.ConfigMapList{}
-	if err := o.client.List(ctx, configMapList, client.InNamespace(namespace)); err != nil {
+	if err := o.client.List(ctx, configMapList, opts...); err != nil {
 		return nil, fmt.Errorf("unable to list ConfigMaps: %w", err)
 	}
 
+	configMaps := make([]client.Object, len(configMapList.Items))
 	for i := range configMapList.Items {
-		configMap := &configMapList.Items[i]
-		if isOrphaned, err := o.isOrphaned(ctx, configMap, namespace); err != nil {
-			return nil, fmt.Errorf("error checking if ConfigMap %s is orphaned: %w", configMap.Name, err)
-		} else if isOrphaned {
-			orphanedConfigMaps = append(orphanedConfigMaps, configMap)
-		}
+		configMapList.Items[i].SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		configMaps[i] = &configMapList.Items[i]
 	}
 
-	return orphanedConfigMaps, nil
+	return configMaps, nil
 }
 
-// isOrphaned checks if a Secret or ConfigMap is orphaned (not referenced by any resources).
-func (o *Orphanage) isOrphaned(ctx context.Context, resource client.Object, namespace string) (bool, error) {
-	// Check if any of these resources reference the secret/configmap
-	resourceTypes := []string{
-		"DaemonSet",
-		"Deployment",
-		"Ingress",
-		"Pod",
-		"ServiceAccount",
-		"StatefulSet",
-	}
+// isOrphaned checks if a Secret or ConfigMap is orphaned (not referenced by any resources in the graph).
+func (o *Orphanage) isOrphaned(referenceGraph *graph.ReferenceGraph, kind string, resource client.Object) bool {
+	return !referenceGraph.IsReferenced(kind, resource.GetNamespace(), resource.GetName())
+}
 
-	for _, resourceType := range resourceTypes {
-		handler := o.handlerRegistry.GetHandler(resourceType)
-		if handler == nil {
-			return false, fmt.Errorf("no handler found for resource type: %s", resourceType)
-		}
+// orphanOf returns the resource to report for an unreferenced resource. Generated resources are recreated
+// by their generator when deleted, so the generator is reported as the actual orphan.
+func orphanOf(referenceGraph *graph.ReferenceGraph, kind string, resource client.Object) client.Object {
+	if generator := referenceGraph.GetGenerator(kind, resource.GetNamespace(), resource.GetName()); generator != nil {
+		return generator
+	}
+	return resource
+}
 
-		references, err := handler.FindReferences(ctx, o.client, resource, namespace)
-		if err != nil {
-			return false, fmt.Errorf("error finding references for %s: %w", resourceType, err)
-		}
+// appendOrphan appends an orphan unless it is already reported
+func appendOrphan(orphans []client.Object, orphan client.Object) []client.Object {
+	if slices.Contains(orphans, orphan) {
+		return orphans
+	}
+	return append(orphans, orphan)
+}
 
-		if len(references) > 0 {
-			return false, nil
-		}
+// listOptions returns the options used to list the resources of a scan in the namespace
+func listOptions(namespace string, scan ResourceScan) []client.ListOption {
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if scan.Selector != nil {
+		opts = append(opts, client.MatchingLabelsSelector{Selector: scan.Selector})
 	}
+	return opts
+}
+
+// isExcluded checks if a resource matches the scan's name exclusions or exclusion rules
+func isExcluded(resource client.Object, scan ResourceScan) bool {
+	return slices.Contains(scan.ExcludeNames, resource.GetName()) || scan.Exclusions.Matches(resource)
+}
 
-	return true, nil
+// isOldEnough checks if a resource has reached the scan's minimum age
+func isOldEnough(resource client.Object, scan ResourceScan, now time.Time) bool {
+	return now.Sub(resource.GetCreationTimestamp().Time) >= scan.MinAge
 }
@@ -0,0 +1,74 @@
+package application
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PayloadSizeAnnotation records the payload size of a Secret or ConfigMap whose payload was stripped
+// by StripPayload. It only exists on cached objects and is never written to the API server.
+const PayloadSizeAnnotation = "kponos.io/payload-size-bytes"
+
+// StripPayload is a cache transform that drops data, binaryData and managedFields from Secrets and
+// ConfigMaps, so that secret material and large payloads are never held in the manager's memory.
+// Only the payload size is kept, in the PayloadSizeAnnotation. Other objects are returned unchanged.
+func StripPayload(obj interface{}) (interface{}, error) {
+	resource, ok := obj.(client.Object)
+	if !ok {
+		return obj, nil
+	}
+
+	switch o := resource.(type) {
+	case *corev1.Secret:
+		setPayloadSize(o, PayloadSize(o))
+		o.Data = nil
+		o.StringData = nil
+	case *corev1.ConfigMap:
+		setPayloadSize(o, PayloadSize(o))
+		o.Data = nil
+		o.BinaryData = nil
+	default:
+		return obj, nil
+	}
+
+	resource.SetManagedFields(nil)
+	return resource, nil
+}
+
+// PayloadSize returns the approximate size of the data held by a Secret or ConfigMap in bytes.
+// For stripped objects the size recorded by StripPayload is returned.
+func PayloadSize(resource client.Object) int64 {
+	if recorded, exists := resource.GetAnnotations()[PayloadSizeAnnotation]; exists {
+		if size, err := strconv.ParseInt(recorded, 10, 64); err == nil {
+			return size
+		}
+	}
+
+	var size int64
+	switch o := resource.(type) {
+	case *corev1.Secret:
+		for key, value := range o.Data {
+			size += int64(len(key) + len(value))
+		}
+	case *corev1.ConfigMap:
+		for key, value := range o.Data {
+			size += int64(len(key) + len(value))
+		}
+		for key, value := range o.BinaryData {
+			size += int64(len(key) + len(value))
+		}
+	}
+	return size
+}
+
+// setPayloadSize records the payload size of a resource in the PayloadSizeAnnotation
+func setPayloadSize(resource client.Object, size int64) {
+	annotations := resource.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[PayloadSizeAnnotation] = strconv.FormatInt(size, 10)
+	resource.SetAnnotations(annotations)
+}
@@ -0,0 +1,283 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	orphanagev1alpha1 "github.com/toKrzysztof/kponos/api/v1alpha1"
+	application "github.com/toKrzysztof/kponos/internal/application/orphanage"
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+	presentation "github.com/toKrzysztof/kponos/internal/presentation"
+)
+
+var clusterLog = logf.Log.WithName("controller_clusterorphanagepolicy")
+
+// ClusterOrphanagePolicyReconciler reconciles a ClusterOrphanagePolicy object
+type ClusterOrphanagePolicyReconciler struct {
+	client.Client
+	Scheme       *runtime.Scheme
+	Orphanage    *application.Orphanage
+	StatusWriter *presentation.StatusWriter
+	// ServedKinds are the optional kinds served by the API server, which are watched besides the built-in kinds
+	ServedKinds core.ServedKinds
+}
+
+// Reconcile scans every namespace selected by a ClusterOrphanagePolicy for orphaned resources
+// and records the per-namespace results in the policy status.
+func (r *ClusterOrphanagePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := clusterLog.WithValues("clusterorphanagepolicy", req.Name)
+	logger.Info("Reconciling ClusterOrphanagePolicy")
+
+	policy := &orphanagev1alpha1.ClusterOrphanagePolicy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		logger.Error(err, "unable to fetch ClusterOrphanagePolicy")
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	start := time.Now()
+
+	scans, err := resourceScans(r.Orphanage, policy.Spec.ResourceTypes, policy.Spec.Exclusions)
+	if err != nil {
+		logger.Error(err, "invalid resource types or exclusions in ClusterOrphanagePolicy")
+		// The spec has to change before a scan can succeed, so the policy is not requeued
+		return ctrl.Result{}, r.StatusWriter.UpdateClusterFailedStatus(ctx, policy, orphanagev1alpha1.ReasonInvalidSpec, err, time.Since(start))
+	}
+
+	selector, err := namespaceSelector(policy)
+	if err != nil {
+		logger.Error(err, "invalid namespace selector in ClusterOrphanagePolicy")
+		return ctrl.Result{}, r.StatusWriter.UpdateClusterFailedStatus(ctx, policy, orphanagev1alpha1.ReasonInvalidSpec, err, time.Since(start))
+	}
+
+	namespaces, err := r.selectNamespaces(ctx, policy, selector)
+	if err != nil {
+		logger.Error(err, "unable to select namespaces")
+		return ctrl.Result{}, r.failScan(ctx, policy, err, start)
+	}
+
+	firstSeen := presentation.ClusterFirstSeenOrphaned(policy)
+	now := time.Now()
+
+	results, err := r.Orphanage.FindOrphansInNamespaces(ctx, namespaces, scans)
+	if err != nil {
+		logger.Error(err, "unable to find orphaned Secrets and ConfigMaps")
+		return ctrl.Result{}, r.failScan(ctx, policy, err, start)
+	}
+
+	var requeueAfter time.Duration
+	reportsByNamespace := make(map[string]application.OrphanReport, len(namespaces))
+	for namespace, result := range results {
+		report := application.NewOrphanReport(result, firstSeen, gracePeriod(policy.Spec.GracePeriod), now)
+		if report.RequeueAfter > 0 && (requeueAfter == 0 || report.RequeueAfter < requeueAfter) {
+			requeueAfter = report.RequeueAfter
+		}
+		reportsByNamespace[namespace] = report
+	}
+
+	logger.Info("Scanned ${namespaces} namespaces", "namespaces", len(namespaces))
+
+	err = r.StatusWriter.UpdateClusterStatus(ctx, policy, reportsByNamespace, time.Since(start))
+	if err != nil {
+		logger.Error(err, "unable to update status")
+		return ctrl.Result{}, err
+	}
+
+	// Come back when the next pending orphan leaves its grace period
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// failScan records a failed scan in the policy status and returns the scan error so that the policy is retried
+func (r *ClusterOrphanagePolicyReconciler) failScan(ctx context.Context, policy *orphanagev1alpha1.ClusterOrphanagePolicy, scanErr error, start time.Time) error {
+	if err := r.StatusWriter.UpdateClusterFailedStatus(ctx, policy, orphanagev1alpha1.ReasonScanFailed, scanErr, time.Since(start)); err != nil {
+		clusterLog.Error(err, "unable to update status", "clusterorphanagepolicy", policy.Name)
+	}
+	return scanErr
+}
+
+// namespaceSelector returns the label selector of the namespaces in scope of the policy.
+// Without a selector all namespaces match, unless namespaces are explicitly included.
+func namespaceSelector(policy *orphanagev1alpha1.ClusterOrphanagePolicy) (labels.Selector, error) {
+	if policy.Spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+		return selector, nil
+	}
+
+	if len(policy.Spec.IncludeNamespaces) > 0 {
+		return labels.Nothing(), nil
+	}
+
+	return labels.Everything(), nil
+}
+
+// selectNamespaces returns the names of the namespaces in scope of the policy.
+// Excluded namespaces are dropped first, then a namespace is selected if it is explicitly
+// included or matches the namespace selector.
+func (r *ClusterOrphanagePolicyReconciler) selectNamespaces(ctx context.Context, policy *orphanagev1alpha1.ClusterOrphanagePolicy, selector labels.Selector) ([]string, error) {
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaceList); err != nil {
+		return nil, fmt.Errorf("unable to list Namespaces: %w", err)
+	}
+
+	var namespaces []string
+	for _, namespace := range namespaceList.Items {
+		if slices.Contains(policy.Spec.ExcludeNamespaces, namespace.Name) {
+			continue
+		}
+		if slices.Contains(policy.Spec.IncludeNamespaces, namespace.Name) || selector.Matches(labels.Set(namespace.Labels)) {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+
+	return namespaces, nil
+}
+
+// clusterPolicyNamespaceIndex indexes ClusterOrphanagePolicy objects by the namespaces they explicitly
+// include, plus allNamespaces for policies that select namespaces by labels
+const clusterPolicyNamespaceIndex = "spec.namespaceScope"
+
+// allNamespaces is the index value of policies whose scope depends on namespace labels
+const allNamespaces = "*"
+
+// indexClusterPolicyNamespaces returns the clusterPolicyNamespaceIndex values of a ClusterOrphanagePolicy
+func indexClusterPolicyNamespaces(obj client.Object) []string {
+	policy, ok := obj.(*orphanagev1alpha1.ClusterOrphanagePolicy)
+	if !ok {
+		return nil
+	}
+
+	scope := slices.Clone(policy.Spec.IncludeNamespaces)
+	if policy.Spec.NamespaceSelector != nil || len(policy.Spec.IncludeNamespaces) == 0 {
+		scope = append(scope, allNamespaces)
+	}
+	return scope
+}
+
+// mapToClusterOrphanagePolicy maps Namespace, Secret/ConfigMap and referencing resource events
+// to reconcile the ClusterOrphanagePolicy objects whose scope covers the namespace of the changed object
+// or one of the namespaces it references
+func (r *ClusterOrphanagePolicyReconciler) mapToClusterOrphanagePolicy(ctx context.Context, obj client.Object) []reconcile.Request {
+	if _, isNamespace := obj.(*corev1.Namespace); isNamespace {
+		return r.clusterPoliciesCovering(ctx, obj.GetName(), true)
+	}
+	if obj.GetNamespace() == "" {
+		// Cluster-scoped objects can reference resources in any namespace
+		return r.enqueueAllClusterPolicies(ctx)
+	}
+
+	var requests []reconcile.Request
+	enqueued := map[string]bool{}
+	for _, namespace := range append([]string{obj.GetNamespace()}, referencedNamespaces(ctx, r.Orphanage, obj)...) {
+		for _, request := range r.clusterPoliciesCovering(ctx, namespace, false) {
+			if !enqueued[request.Name] {
+				enqueued[request.Name] = true
+				requests = append(requests, request)
+			}
+		}
+	}
+
+	return requests
+}
+
+// clusterPoliciesCovering returns a request for every ClusterOrphanagePolicy whose scope covers the namespace.
+// For a Namespace event every policy that can select the namespace is returned.
+func (r *ClusterOrphanagePolicyReconciler) clusterPoliciesCovering(ctx context.Context, namespaceName string, isNamespace bool) []reconcile.Request {
+	var policies []orphanagev1alpha1.ClusterOrphanagePolicy
+	for _, scope := range []string{namespaceName, allNamespaces} {
+		policyList := &orphanagev1alpha1.ClusterOrphanagePolicyList{}
+		if err := r.List(ctx, policyList, client.MatchingFields{clusterPolicyNamespaceIndex: scope}); err != nil {
+			clusterLog.Error(err, "unable to list ClusterOrphanagePolicy objects", "namespace", namespaceName)
+			return []reconcile.Request{}
+		}
+		policies = append(policies, policyList.Items...)
+	}
+
+	// Label changes of a Namespace can move it in or out of scope, so every selecting policy is
+	// enqueued for them. For other objects the current Namespace labels decide; if the Namespace
+	// cannot be read, the policy is enqueued anyway.
+	var namespaceLabels labels.Set
+	if !isNamespace {
+		namespace := &corev1.Namespace{}
+		if err := r.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace); err == nil {
+			namespaceLabels = labels.Set(namespace.Labels)
+		}
+	}
+
+	requests := make([]reconcile.Request, 0, len(policies))
+	enqueued := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		if enqueued[policy.Name] || slices.Contains(policy.Spec.ExcludeNamespaces, namespaceName) {
+			continue
+		}
+
+		if namespaceLabels != nil && !slices.Contains(policy.Spec.IncludeNamespaces, namespaceName) {
+			selector, err := namespaceSelector(&policy)
+			if err == nil && !selector.Matches(namespaceLabels) {
+				continue
+			}
+		}
+
+		enqueued[policy.Name] = true
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name: policy.Name,
+			},
+		})
+	}
+
+	return requests
+}
+
+// enqueueAllClusterPolicies returns a request for every ClusterOrphanagePolicy
+func (r *ClusterOrphanagePolicyReconciler) enqueueAllClusterPolicies(ctx context.Context) []reconcile.Request {
+	policyList := &orphanagev1alpha1.ClusterOrphanagePolicyList{}
+	if err := r.List(ctx, policyList); err != nil {
+		clusterLog.Error(err, "unable to list ClusterOrphanagePolicy objects")
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, 0, len(policyList.Items))
+	for _, policy := range policyList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name: policy.Name,
+			},
+		})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterOrphanagePolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &orphanagev1alpha1.ClusterOrphanagePolicy{},
+		clusterPolicyNamespaceIndex, indexClusterPolicyNamespaces); err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		// Status writes do not bump the generation, so they do not trigger another scan
+		For(&orphanagev1alpha1.ClusterOrphanagePolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("clusterorphanagepolicy").
+		// Namespaces only matter when they appear, disappear or their labels change
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.mapToClusterOrphanagePolicy),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}))
+	return watchScannedResources(b, r.ServedKinds, r.mapToClusterOrphanagePolicy).Complete(r)
+}
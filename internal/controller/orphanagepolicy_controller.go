@@ -2,21 +2,25 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"time"
 
-	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	orphanagev1alpha1 "github.com/toKrzysztof/kponos/api/v1alpha1"
 	application "github.com/toKrzysztof/kponos/internal/application/orphanage"
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
 	presentation "github.com/toKrzysztof/kponos/internal/presentation"
-	appsv1 "k8s.io/api/apps/v1"
-	ingressv1 "k8s.io/api/networking/v1"
 )
 
 var log = logf.Log.WithName("controller_orphanagepolicy")
@@ -27,6 +31,8 @@ type OrphanagePolicyReconciler struct {
 	Scheme       *runtime.Scheme
 	Orphanage    *application.Orphanage
 	StatusWriter *presentation.StatusWriter
+	// ServedKinds are the optional kinds served by the API server, which are watched besides the built-in kinds
+	ServedKinds core.ServedKinds
 }
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -41,68 +47,177 @@ func (r *OrphanagePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	orphanedSecrets, err := r.Orphanage.FindOrphans(ctx, "Secret", req.Namespace)
+	start := time.Now()
+
+	scans, err := resourceScans(r.Orphanage, policy.Spec.ResourceTypes, policy.Spec.Exclusions)
 	if err != nil {
-		logger.Error(err, "unable to find orphaned Secrets")
-		return ctrl.Result{}, err
+		logger.Error(err, "invalid resource types or exclusions in OrphanagePolicy")
+		// The spec has to change before a scan can succeed, so the policy is not requeued
+		return ctrl.Result{}, r.StatusWriter.UpdateFailedStatus(ctx, policy, orphanagev1alpha1.ReasonInvalidSpec, err, time.Since(start))
 	}
 
-	logger.Info("Found ${orphanedSecrets} orphaned Secrets", "orphanedSecrets", len(orphanedSecrets))
-
-	orphanedConfigMaps, err := r.Orphanage.FindOrphans(ctx, "ConfigMap", req.Namespace)
+	result, err := r.Orphanage.FindOrphans(ctx, req.Namespace, scans)
 	if err != nil {
-		logger.Error(err, "unable to find orphaned ConfigMaps")
-		return ctrl.Result{}, err
+		logger.Error(err, "unable to find orphaned Secrets and ConfigMaps")
+		return ctrl.Result{}, r.failScan(ctx, policy, err, start)
 	}
 
-	logger.Info("Found ${orphanedConfigMaps} orphaned Configmaps", "orphanedConfigMaps", len(orphanedConfigMaps))
+	report := application.NewOrphanReport(result, presentation.FirstSeenOrphaned(policy), gracePeriod(policy.Spec.GracePeriod), time.Now())
 
-	orphans := append(orphanedSecrets, orphanedConfigMaps...)
+	logger.Info("Found ${orphans} orphaned Secrets and ConfigMaps", "orphans", len(report.Orphans), "pending", len(report.Pending), "excluded", len(report.Excluded))
 
-	err = r.StatusWriter.UpdateStatus(ctx, policy, orphans)
+	err = r.StatusWriter.UpdateStatus(ctx, policy, report, time.Since(start))
 	if err != nil {
 		logger.Error(err, "unable to update status")
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Come back when the next pending orphan leaves its grace period
+	return ctrl.Result{RequeueAfter: report.RequeueAfter}, nil
 }
 
-// mapToOrphanagePolicy maps Secret/ConfigMap events to reconcile all OrphanagePolicy objects
-func (r *OrphanagePolicyReconciler) mapToOrphanagePolicy(ctx context.Context, obj client.Object) []reconcile.Request {
-	policyList := &orphanagev1alpha1.OrphanagePolicyList{}
-	if err := r.List(ctx, policyList); err != nil {
-		log.Error(err, "unable to list OrphanagePolicy objects")
-		return []reconcile.Request{}
+// failScan records a failed scan in the policy status and returns the scan error so that the policy is retried
+func (r *OrphanagePolicyReconciler) failScan(ctx context.Context, policy *orphanagev1alpha1.OrphanagePolicy, scanErr error, start time.Time) error {
+	if err := r.StatusWriter.UpdateFailedStatus(ctx, policy, orphanagev1alpha1.ReasonScanFailed, scanErr, time.Since(start)); err != nil {
+		log.Error(err, "unable to update status", "orphanagepolicy", client.ObjectKeyFromObject(policy))
+	}
+	return scanErr
+}
+
+// gracePeriod returns the duration of a policy's grace period, or zero if none is set
+func gracePeriod(duration *metav1.Duration) time.Duration {
+	if duration == nil {
+		return 0
+	}
+	return duration.Duration
+}
+
+// resourceScans translates a policy's resource types and exclusions into the scans run by the Orphanage.
+// A policy without resource types scans every supported kind.
+func resourceScans(orphanage *application.Orphanage, resourceTypes []orphanagev1alpha1.ResourceTypeSpec, exclusions *orphanagev1alpha1.Exclusions) ([]application.ResourceScan, error) {
+	scanExclusions, err := toExclusions(exclusions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resourceTypes) == 0 {
+		supported := orphanage.SupportedResourceTypes()
+		scans := make([]application.ResourceScan, 0, len(supported))
+		for _, resourceType := range supported {
+			scans = append(scans, application.ResourceScan{
+				ResourceType: resourceType,
+				Exclusions:   scanExclusions,
+			})
+		}
+		return scans, nil
+	}
+
+	scans := make([]application.ResourceScan, 0, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		scan := application.ResourceScan{
+			ResourceType: string(resourceType.Kind),
+			ExcludeNames: resourceType.ExcludeNames,
+			Exclusions:   scanExclusions,
+		}
+
+		if resourceType.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(resourceType.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector for %s: %w", resourceType.Kind, err)
+			}
+			scan.Selector = selector
+		}
+
+		if resourceType.MinAge != nil {
+			scan.MinAge = resourceType.MinAge.Duration
+		}
+
+		scans = append(scans, scan)
+	}
+
+	return scans, nil
+}
+
+// toExclusions validates a policy's exclusions and translates them into the rules applied by the Orphanage
+func toExclusions(exclusions *orphanagev1alpha1.Exclusions) (application.Exclusions, error) {
+	if exclusions == nil {
+		return application.Exclusions{}, nil
 	}
 
-	requests := make([]reconcile.Request, 0, len(policyList.Items))
+	result := application.Exclusions{
+		Annotations:  exclusions.Annotations,
+		NamePatterns: exclusions.NamePatterns,
+	}
 
-	// Enqueue all policies
-	for _, policy := range policyList.Items {
-		requests = append(requests, reconcile.Request{
-			NamespacedName: types.NamespacedName{
-				Name:      policy.Name,
-				Namespace: policy.Namespace,
-			},
-		})
+	if exclusions.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(exclusions.LabelSelector)
+		if err != nil {
+			return application.Exclusions{}, fmt.Errorf("invalid exclusion label selector: %w", err)
+		}
+		result.Selector = selector
+	}
+
+	for _, pattern := range exclusions.NamePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return application.Exclusions{}, fmt.Errorf("invalid exclusion name pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, expression := range exclusions.NameRegexes {
+		regex, err := regexp.Compile(expression)
+		if err != nil {
+			return application.Exclusions{}, fmt.Errorf("invalid exclusion name regex %q: %w", expression, err)
+		}
+		result.NameRegexes = append(result.NameRegexes, regex)
+	}
+
+	return result, nil
+}
+
+// mapToOrphanagePolicy maps Secret/ConfigMap and referencing resource events to reconcile the
+// OrphanagePolicy objects in the namespace of the changed object and the namespaces it references
+func (r *OrphanagePolicyReconciler) mapToOrphanagePolicy(ctx context.Context, obj client.Object) []reconcile.Request {
+	// An OrphanagePolicy only scans its own namespace; the cache serves this from its namespace index.
+	// Cluster-scoped objects have no namespace and can reference resources in any of them, so they
+	// enqueue every policy.
+	namespaces := append([]string{obj.GetNamespace()}, referencedNamespaces(ctx, r.Orphanage, obj)...)
+
+	var requests []reconcile.Request
+	for _, namespace := range namespaces {
+		policyList := &orphanagev1alpha1.OrphanagePolicyList{}
+		if err := r.List(ctx, policyList, client.InNamespace(namespace)); err != nil {
+			log.Error(err, "unable to list OrphanagePolicy objects", "namespace", namespace)
+			return []reconcile.Request{}
+		}
+
+		for _, policy := range policyList.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      policy.Name,
+					Namespace: policy.Namespace,
+				},
+			})
+		}
 	}
 
 	return requests
 }
 
+// referencedNamespaces returns the namespaces other than its own whose orphans can change with an object.
+// If they cannot be determined, only the namespace of the object is rescanned.
+func referencedNamespaces(ctx context.Context, orphanage *application.Orphanage, obj client.Object) []string {
+	namespaces, err := orphanage.ReferencedNamespaces(ctx, obj)
+	if err != nil {
+		log.Error(err, "unable to determine referenced namespaces", "object", client.ObjectKeyFromObject(obj))
+	}
+	return namespaces
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *OrphanagePolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&orphanagev1alpha1.OrphanagePolicy{}).
-		Named("orphanagepolicy").
-		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&ingressv1.Ingress{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestsFromMapFunc(r.mapToOrphanagePolicy)).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		// Status writes do not bump the generation, so they do not trigger another scan
+		For(&orphanagev1alpha1.OrphanagePolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named("orphanagepolicy")
+	return watchScannedResources(b, r.ServedKinds, r.mapToOrphanagePolicy).Complete(r)
 }
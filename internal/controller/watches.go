@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"maps"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	ingressv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	application "github.com/toKrzysztof/kponos/internal/application/orphanage"
+	core "github.com/toKrzysztof/kponos/internal/core/reference_analyzer"
+)
+
+// volumeSnapshotKind is the kind of VolumeSnapshot, whose status is read by the VolumeSnapshotClass finder
+var volumeSnapshotKind = schema.GroupKind{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshot"}
+
+// scannedResourceChanged lets through Secret and ConfigMap updates that can change whether they are
+// excluded. Content changes do not affect whether a resource is referenced.
+var scannedResourceChanged = predicate.Or(predicate.LabelChangedPredicate{}, scannedAnnotationsChanged)
+
+// scannedAnnotationsChanged lets through Secret and ConfigMap updates that change the annotations. The payload
+// size annotation is recorded by the cache transform and changes with the content, so it is ignored.
+var scannedAnnotationsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return true
+		}
+		return !maps.Equal(withoutPayloadSize(e.ObjectOld.GetAnnotations()), withoutPayloadSize(e.ObjectNew.GetAnnotations()))
+	},
+}
+
+// podSpecChanged lets through Pod updates that change the spec, ignoring status-only changes
+var podSpecChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldPod, oldOk := e.ObjectOld.(*corev1.Pod)
+		newPod, newOk := e.ObjectNew.(*corev1.Pod)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldPod.Spec, newPod.Spec)
+	},
+}
+
+// podTemplateChanged lets through PodTemplate updates that change the template. PodTemplates have
+// no generation, so GenerationChangedPredicate would drop all of their updates.
+var podTemplateChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldTemplate, oldOk := e.ObjectOld.(*corev1.PodTemplate)
+		newTemplate, newOk := e.ObjectNew.(*corev1.PodTemplate)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldTemplate.Template.Spec, newTemplate.Template.Spec)
+	},
+}
+
+// serviceAccountSecretsChanged lets through ServiceAccount updates that change the referenced Secrets
+var serviceAccountSecretsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldAccount, oldOk := e.ObjectOld.(*corev1.ServiceAccount)
+		newAccount, newOk := e.ObjectNew.(*corev1.ServiceAccount)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldAccount.Secrets, newAccount.Secrets) ||
+			!equality.Semantic.DeepEqual(oldAccount.ImagePullSecrets, newAccount.ImagePullSecrets)
+	},
+}
+
+// persistentVolumeSecretsChanged lets through PersistentVolume updates that change the CSI secret references,
+// including the provisioner deletion Secret kept in the annotations
+var persistentVolumeSecretsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldVolume, oldOk := e.ObjectOld.(*corev1.PersistentVolume)
+		newVolume, newOk := e.ObjectNew.(*corev1.PersistentVolume)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldVolume.Spec.CSI, newVolume.Spec.CSI) ||
+			!equality.Semantic.DeepEqual(oldVolume.Annotations, newVolume.Annotations)
+	},
+}
+
+// claimChanged lets through PersistentVolumeClaim updates that change the values of templated StorageClass parameters
+var claimChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldClaim, oldOk := e.ObjectOld.(*corev1.PersistentVolumeClaim)
+		newClaim, newOk := e.ObjectNew.(*corev1.PersistentVolumeClaim)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldClaim.Spec, newClaim.Spec) ||
+			!equality.Semantic.DeepEqual(oldClaim.Annotations, newClaim.Annotations)
+	},
+}
+
+// volumeSnapshotContentChanged lets through VolumeSnapshot updates that bind the snapshot to its content.
+// The binding is recorded in the status, which does not bump the generation, and resolves the
+// ${volumesnapshotcontent.name} variable of VolumeSnapshotClass parameters.
+var volumeSnapshotContentChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSnapshot, oldOk := e.ObjectOld.(*unstructured.Unstructured)
+		newSnapshot, newOk := e.ObjectNew.(*unstructured.Unstructured)
+		if !oldOk || !newOk {
+			return true
+		}
+		oldContent, _, _ := unstructured.NestedString(oldSnapshot.Object, "status", "boundVolumeSnapshotContentName")
+		newContent, _, _ := unstructured.NestedString(newSnapshot.Object, "status", "boundVolumeSnapshotContentName")
+		return oldContent != newContent
+	},
+}
+
+// ingressClassParametersChanged lets through IngressClass updates that change the parameters reference
+var ingressClassParametersChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldClass, oldOk := e.ObjectOld.(*ingressv1.IngressClass)
+		newClass, newOk := e.ObjectNew.(*ingressv1.IngressClass)
+		if !oldOk || !newOk {
+			return true
+		}
+		return !equality.Semantic.DeepEqual(oldClass.Spec.Parameters, newClass.Spec.Parameters)
+	},
+}
+
+// withoutPayloadSize returns the annotations without the payload size annotation
+func withoutPayloadSize(annotations map[string]string) map[string]string {
+	if _, exists := annotations[application.PayloadSizeAnnotation]; !exists {
+		return annotations
+	}
+	result := maps.Clone(annotations)
+	delete(result, application.PayloadSizeAnnotation)
+	return result
+}
+
+// watchScannedResources adds watches for the scanned resources and every resource that can reference them.
+// Updates that cannot change the outcome of a scan are filtered out before they are mapped to policies.
+// Optional kinds are only watched if the API server serves them.
+func watchScannedResources(b *builder.Builder, servedKinds core.ServedKinds, mapFunc handler.MapFunc) *builder.Builder {
+	enqueue := handler.EnqueueRequestsFromMapFunc(mapFunc)
+	b = b.
+		Watches(&corev1.Secret{}, enqueue, builder.WithPredicates(scannedResourceChanged)).
+		Watches(&corev1.ConfigMap{}, enqueue, builder.WithPredicates(scannedResourceChanged)).
+		Watches(&corev1.ServiceAccount{}, enqueue, builder.WithPredicates(serviceAccountSecretsChanged)).
+		// Ingress controllers read references from annotations, which do not bump the generation
+		Watches(&ingressv1.Ingress{}, enqueue, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&ingressv1.IngressClass{}, enqueue, builder.WithPredicates(ingressClassParametersChanged)).
+		Watches(&corev1.Pod{}, enqueue, builder.WithPredicates(podSpecChanged)).
+		// Argo CD components are found by label, which does not bump the generation
+		Watches(&appsv1.Deployment{}, enqueue, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Watches(&appsv1.StatefulSet{}, enqueue, builder.WithPredicates(
+			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		Watches(&appsv1.DaemonSet{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&batchv1.Job{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&batchv1.CronJob{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&appsv1.ReplicaSet{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.ReplicationController{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.PodTemplate{}, enqueue, builder.WithPredicates(podTemplateChanged)).
+		// ControllerRevisions are immutable, only their creation and deletion matter
+		Watches(&appsv1.ControllerRevision{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// StorageClass parameters are immutable
+		Watches(&storagev1.StorageClass{}, enqueue, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.PersistentVolume{}, enqueue, builder.WithPredicates(persistentVolumeSecretsChanged)).
+		Watches(&corev1.PersistentVolumeClaim{}, enqueue, builder.WithPredicates(claimChanged))
+
+	for _, gvk := range servedKinds {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		var changed predicate.Predicate = predicate.GenerationChangedPredicate{}
+		if gvk.GroupKind() == volumeSnapshotKind {
+			changed = predicate.Or(changed, volumeSnapshotContentChanged)
+		}
+		b = b.Watches(obj, enqueue, builder.WithPredicates(changed))
+	}
+
+	return b
+}
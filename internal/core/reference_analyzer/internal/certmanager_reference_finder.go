@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"context"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CertManagerResourceType represents a valid cert-manager resource type
+type CertManagerResourceType string
+
+const (
+	CertManagerResourceTypeIssuer        CertManagerResourceType = "Issuer"
+	CertManagerResourceTypeClusterIssuer CertManagerResourceType = "ClusterIssuer"
+	CertManagerResourceTypeCertificate   CertManagerResourceType = "Certificate"
+)
+
+// certManagerGroup is the API group of cert-manager
+const certManagerGroup = "cert-manager.io"
+
+// CertManagerReferenceFinder finds the Secrets of cert-manager resources: the ACME, DNS-01 and CA credentials
+// of Issuers and ClusterIssuers, and the key pair and keystore password Secrets of Certificates.
+type CertManagerReferenceFinder struct {
+	client.Client
+	resourceType             CertManagerResourceType
+	servedKinds              ServedKinds
+	clusterResourceNamespace string
+}
+
+// NewCertManagerReferenceFinder creates a new CertManagerReferenceFinder for the given resource type.
+// The Secrets of ClusterIssuers are looked up in the cluster resource namespace of cert-manager.
+func NewCertManagerReferenceFinder(c client.Client, resourceType CertManagerResourceType, servedKinds ServedKinds, clusterResourceNamespace string) *CertManagerReferenceFinder {
+	return &CertManagerReferenceFinder{
+		Client:                   c,
+		resourceType:             resourceType,
+		servedKinds:              servedKinds,
+		clusterResourceNamespace: clusterResourceNamespace,
+	}
+}
+
+// CollectReferences adds every Secret referenced by the cert-manager resources to the graph
+func (f *CertManagerReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	switch f.resourceType {
+	case CertManagerResourceTypeIssuer:
+		issuers, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: certManagerGroup, Kind: "Issuer"}, client.InNamespace(namespace))
+		if err != nil || issuers == nil {
+			return err
+		}
+		for i := range issuers.Items {
+			f.collectIssuerReferences(&issuers.Items[i], namespace, g)
+		}
+
+	case CertManagerResourceTypeClusterIssuer:
+		// ClusterIssuers are cluster-scoped and read their Secrets from the cluster resource namespace
+		clusterIssuers, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: certManagerGroup, Kind: "ClusterIssuer"})
+		if err != nil || clusterIssuers == nil {
+			return err
+		}
+		for i := range clusterIssuers.Items {
+			f.collectIssuerReferences(&clusterIssuers.Items[i], f.clusterResourceNamespace, g)
+		}
+
+	case CertManagerResourceTypeCertificate:
+		certificates, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: certManagerGroup, Kind: "Certificate"}, client.InNamespace(namespace))
+		if err != nil || certificates == nil {
+			return err
+		}
+		for i := range certificates.Items {
+			f.collectCertificateReferences(&certificates.Items[i], g)
+		}
+	}
+
+	return nil
+}
+
+// collectIssuerReferences adds the Secrets referenced by an Issuer or ClusterIssuer to the graph:
+// ACME account keys, external account binding keys, DNS-01 solver credentials, the CA key pair and
+// Vault or Venafi credentials. They are looked up in the given namespace.
+func (f *CertManagerReferenceFinder) collectIssuerReferences(issuer *unstructured.Unstructured, namespace string, g *graph.ReferenceGraph) {
+	spec, _, _ := unstructured.NestedMap(issuer.Object, "spec")
+
+	// Check every *SecretRef, secretRef and credentialsRef, e.g. spec.acme.privateKeySecretRef.name
+	// and spec.acme.solvers[].dns01.route53.secretAccessKeySecretRef.name
+	for _, ref := range nestedSecretReferences(spec) {
+		g.AddSecretReference(issuer, namespace, ref.name)
+	}
+
+	// Check spec.ca.secretName
+	if secretName, _, _ := unstructured.NestedString(spec, "ca", "secretName"); secretName != "" {
+		g.AddSecretReference(issuer, namespace, secretName)
+	}
+}
+
+// collectCertificateReferences adds the Secret a Certificate writes its key pair to, so that the Secret
+// stays referenced for as long as the Certificate exists, and the keystore password Secrets
+func (f *CertManagerReferenceFinder) collectCertificateReferences(certificate *unstructured.Unstructured, g *graph.ReferenceGraph) {
+	spec, _, _ := unstructured.NestedMap(certificate.Object, "spec")
+
+	// Check spec.secretName
+	secretName, _, _ := unstructured.NestedString(spec, "secretName")
+	g.AddSecretReference(certificate, certificate.GetNamespace(), secretName)
+
+	// Check spec.keystores.jks.passwordSecretRef.name and spec.keystores.pkcs12.passwordSecretRef.name
+	for _, ref := range nestedSecretReferences(spec) {
+		g.AddSecretReference(certificate, certificate.GetNamespace(), ref.name)
+	}
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *CertManagerReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
@@ -0,0 +1,362 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ExternalSecretsResourceType represents a valid External Secrets Operator resource type
+type ExternalSecretsResourceType string
+
+const (
+	ExternalSecretsResourceTypeExternalSecret     ExternalSecretsResourceType = "ExternalSecret"
+	ExternalSecretsResourceTypePushSecret         ExternalSecretsResourceType = "PushSecret"
+	ExternalSecretsResourceTypeSecretStore        ExternalSecretsResourceType = "SecretStore"
+	ExternalSecretsResourceTypeClusterSecretStore ExternalSecretsResourceType = "ClusterSecretStore"
+)
+
+// externalSecretsGroup is the API group of the External Secrets Operator
+const externalSecretsGroup = "external-secrets.io"
+
+// ExternalSecretsReferenceFinder finds the provider credentials of SecretStores and ClusterSecretStores, the
+// template sources of ExternalSecrets and the Secrets pushed by PushSecrets. It also records the target
+// Secrets of ExternalSecrets as generated by them.
+type ExternalSecretsReferenceFinder struct {
+	client.Client
+	resourceType ExternalSecretsResourceType
+	servedKinds  ServedKinds
+}
+
+// NewExternalSecretsReferenceFinder creates a new ExternalSecretsReferenceFinder for the given resource type
+func NewExternalSecretsReferenceFinder(c client.Client, resourceType ExternalSecretsResourceType, servedKinds ServedKinds) *ExternalSecretsReferenceFinder {
+	return &ExternalSecretsReferenceFinder{
+		Client:       c,
+		resourceType: resourceType,
+		servedKinds:  servedKinds,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced or generated by the External Secrets Operator
+// resources to the graph
+func (f *ExternalSecretsReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	switch f.resourceType {
+	case ExternalSecretsResourceTypeExternalSecret:
+		externalSecrets, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: externalSecretsGroup, Kind: "ExternalSecret"}, client.InNamespace(namespace))
+		if err != nil || externalSecrets == nil {
+			return err
+		}
+		for i := range externalSecrets.Items {
+			f.collectExternalSecretReferences(&externalSecrets.Items[i], g)
+		}
+
+	case ExternalSecretsResourceTypePushSecret:
+		pushSecrets, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: externalSecretsGroup, Kind: "PushSecret"}, client.InNamespace(namespace))
+		if err != nil || pushSecrets == nil {
+			return err
+		}
+		for i := range pushSecrets.Items {
+			pushSecret := &pushSecrets.Items[i]
+			// Check spec.selector.secret.name, the Secret that is pushed to the provider
+			secretName, _, _ := unstructured.NestedString(pushSecret.Object, "spec", "selector", "secret", "name")
+			g.AddSecretReference(pushSecret, pushSecret.GetNamespace(), secretName)
+		}
+
+	case ExternalSecretsResourceTypeSecretStore:
+		secretStores, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: externalSecretsGroup, Kind: "SecretStore"}, client.InNamespace(namespace))
+		if err != nil || secretStores == nil {
+			return err
+		}
+		for i := range secretStores.Items {
+			secretStore := &secretStores.Items[i]
+			// A SecretStore can only read credentials from its own namespace, whatever the reference says
+			for _, ref := range secretStoreReferences(secretStore) {
+				g.AddReference(secretStore, ref.kind, secretStore.GetNamespace(), ref.name)
+			}
+		}
+
+	case ExternalSecretsResourceTypeClusterSecretStore:
+		return f.collectClusterSecretStoreReferences(ctx, c, g)
+	}
+
+	return nil
+}
+
+// collectClusterSecretStoreReferences adds the credentials of ClusterSecretStores to the graph. ClusterSecretStores
+// are cluster-scoped, so they are listed across the cluster. A reference without a namespace is resolved in the
+// namespace of each ExternalSecret or PushSecret that uses the store, so it is recorded in those namespaces.
+func (f *ExternalSecretsReferenceFinder) collectClusterSecretStoreReferences(ctx context.Context, c client.Client, g *graph.ReferenceGraph) error {
+	clusterSecretStores, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: externalSecretsGroup, Kind: "ClusterSecretStore"})
+	if err != nil || clusterSecretStores == nil {
+		return err
+	}
+
+	var storeNamespaces map[string][]string
+	for i := range clusterSecretStores.Items {
+		clusterSecretStore := &clusterSecretStores.Items[i]
+		for _, ref := range secretStoreReferences(clusterSecretStore) {
+			if ref.namespace != "" {
+				g.AddReference(clusterSecretStore, ref.kind, ref.namespace, ref.name)
+				continue
+			}
+			if storeNamespaces == nil {
+				if storeNamespaces, err = f.clusterSecretStoreNamespaces(ctx, c, clusterSecretStores.Items); err != nil {
+					return err
+				}
+			}
+			for _, namespace := range storeNamespaces[clusterSecretStore.GetName()] {
+				g.AddReference(clusterSecretStore, ref.kind, namespace, ref.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// clusterSecretStoreNamespaces maps the name of every ClusterSecretStore to the namespaces of the
+// ExternalSecrets and PushSecrets that use it
+func (f *ExternalSecretsReferenceFinder) clusterSecretStoreNamespaces(ctx context.Context, c client.Client, stores []unstructured.Unstructured) (map[string][]string, error) {
+	storeNamespaces := map[string][]string{}
+	addNamespace := func(store, namespace string) {
+		if !slices.Contains(storeNamespaces[store], namespace) {
+			storeNamespaces[store] = append(storeNamespaces[store], namespace)
+		}
+	}
+
+	externalSecrets, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: externalSecretsGroup, Kind: "ExternalSecret"})
+	if err != nil {
+		return nil, err
+	}
+	if externalSecrets != nil {
+		for i := range externalSecrets.Items {
+			externalSecret := &externalSecrets.Items[i]
+			for _, store := range externalSecretClusterStores(externalSecret) {
+				addNamespace(store, externalSecret.GetNamespace())
+			}
+		}
+	}
+
+	pushSecrets, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: externalSecretsGroup, Kind: "PushSecret"})
+	if err != nil {
+		return nil, err
+	}
+	if pushSecrets != nil {
+		for i := range pushSecrets.Items {
+			pushSecret := &pushSecrets.Items[i]
+			for _, store := range pushSecretClusterStores(pushSecret, stores) {
+				addNamespace(store, pushSecret.GetNamespace())
+			}
+		}
+	}
+
+	return storeNamespaces, nil
+}
+
+// externalSecretClusterStores returns the names of the ClusterSecretStores an ExternalSecret reads from:
+// spec.secretStoreRef and the spec.data[].sourceRef.storeRef and spec.dataFrom[].sourceRef.storeRef overrides
+func externalSecretClusterStores(externalSecret *unstructured.Unstructured) []string {
+	var stores []string
+	addStore := func(storeRef map[string]interface{}) {
+		kind, _, _ := unstructured.NestedString(storeRef, "kind")
+		name, _, _ := unstructured.NestedString(storeRef, "name")
+		if kind == "ClusterSecretStore" && name != "" {
+			stores = append(stores, name)
+		}
+	}
+
+	if storeRef, found, _ := unstructured.NestedMap(externalSecret.Object, "spec", "secretStoreRef"); found {
+		addStore(storeRef)
+	}
+	for _, field := range []string{"data", "dataFrom"} {
+		entries, _, _ := unstructured.NestedSlice(externalSecret.Object, "spec", field)
+		for _, entry := range entries {
+			entryObject, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if storeRef, found, _ := unstructured.NestedMap(entryObject, "sourceRef", "storeRef"); found {
+				addStore(storeRef)
+			}
+		}
+	}
+
+	return stores
+}
+
+// pushSecretClusterStores returns the names of the ClusterSecretStores a PushSecret pushes to, named in
+// spec.secretStoreRefs[] or selected by their labelSelector
+func pushSecretClusterStores(pushSecret *unstructured.Unstructured, stores []unstructured.Unstructured) []string {
+	var names []string
+	storeRefs, _, _ := unstructured.NestedSlice(pushSecret.Object, "spec", "secretStoreRefs")
+	for _, storeRef := range storeRefs {
+		storeRefObject, ok := storeRef.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, _, _ := unstructured.NestedString(storeRefObject, "kind"); kind != "ClusterSecretStore" {
+			continue
+		}
+		if name, _, _ := unstructured.NestedString(storeRefObject, "name"); name != "" {
+			names = append(names, name)
+		}
+
+		labelSelector, found, _ := unstructured.NestedMap(storeRefObject, "labelSelector")
+		if !found {
+			continue
+		}
+		selector := &metav1.LabelSelector{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(labelSelector, selector); err != nil {
+			continue
+		}
+		matcher, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			continue
+		}
+		for i := range stores {
+			if matcher.Matches(labels.Set(stores[i].GetLabels())) {
+				names = append(names, stores[i].GetName())
+			}
+		}
+	}
+
+	return names
+}
+
+// collectExternalSecretReferences adds the template Secrets and ConfigMaps an ExternalSecret reads, and the
+// target Secret it generates, to the graph. The target Secret is only generated with the Owner and Orphan
+// creation policies; with Merge the Secret is created by someone else and with None no Secret is written.
+func (f *ExternalSecretsReferenceFinder) collectExternalSecretReferences(externalSecret *unstructured.Unstructured, g *graph.ReferenceGraph) {
+	target, _, _ := unstructured.NestedMap(externalSecret.Object, "spec", "target")
+
+	// Check spec.target.name, which defaults to the name of the ExternalSecret
+	creationPolicy, _, _ := unstructured.NestedString(target, "creationPolicy")
+	if creationPolicy == "" || creationPolicy == "Owner" || creationPolicy == "Orphan" {
+		targetName, _, _ := unstructured.NestedString(target, "name")
+		if targetName == "" {
+			targetName = externalSecret.GetName()
+		}
+		g.AddGenerator(externalSecret, graph.KindSecret, externalSecret.GetNamespace(), targetName)
+	}
+
+	// Check spec.target.template.templateFrom[].configMap.name and spec.target.template.templateFrom[].secret.name
+	templateFrom, _, _ := unstructured.NestedSlice(target, "template", "templateFrom")
+	for _, source := range templateFrom {
+		sourceObject, ok := source.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		configMapName, _, _ := unstructured.NestedString(sourceObject, "configMap", "name")
+		g.AddConfigMapReference(externalSecret, externalSecret.GetNamespace(), configMapName)
+		secretName, _, _ := unstructured.NestedString(sourceObject, "secret", "name")
+		g.AddSecretReference(externalSecret, externalSecret.GetNamespace(), secretName)
+	}
+}
+
+// storeReference is a Secret or ConfigMap read by the provider of a SecretStore or ClusterSecretStore.
+// The namespace is empty unless the reference sets it.
+type storeReference struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// secretStoreReferences returns the Secrets and ConfigMaps read by the provider of a SecretStore or
+// ClusterSecretStore: every secret reference by naming convention, every key selector under an auth field
+// of the provider, e.g. spec.provider.azurekv.authSecretRef.clientSecret or
+// spec.provider.kubernetes.auth.token.bearerToken, and the Secret or ConfigMap of every caProvider
+func secretStoreReferences(secretStore *unstructured.Unstructured) []storeReference {
+	provider, _, _ := unstructured.NestedMap(secretStore.Object, "spec", "provider")
+
+	var refs []storeReference
+	addSecrets := func(secretRefs []secretReference) {
+		for _, ref := range secretRefs {
+			refs = append(refs, storeReference{kind: graph.KindSecret, namespace: ref.namespace, name: ref.name})
+		}
+	}
+
+	addSecrets(nestedSecretReferences(provider))
+	for _, config := range provider {
+		configObject, ok := config.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, auth := range configObject {
+			if strings.HasPrefix(key, "auth") {
+				addSecrets(nestedKeySelectors(auth))
+			}
+		}
+	}
+
+	return append(refs, caProviderReferences(provider)...)
+}
+
+// nestedKeySelectors walks the auth settings of a provider and returns every key selector: an object with
+// a "name" and a "key", which External Secrets uses to select a key of a Secret. A caProvider also has both,
+// but can name a ConfigMap, so it is left to caProviderReferences.
+func nestedKeySelectors(value interface{}) []secretReference {
+	var refs []secretReference
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		name, _, _ := unstructured.NestedString(v, "name")
+		if _, hasKey := v["key"]; hasKey && name != "" {
+			namespace, _, _ := unstructured.NestedString(v, "namespace")
+			refs = append(refs, secretReference{namespace: namespace, name: name})
+		}
+		for key, nested := range v {
+			if key != "caProvider" {
+				refs = append(refs, nestedKeySelectors(nested)...)
+			}
+		}
+	case []interface{}:
+		for _, nested := range v {
+			refs = append(refs, nestedKeySelectors(nested)...)
+		}
+	}
+
+	return refs
+}
+
+// caProviderReferences walks the settings of a provider and returns the Secret or ConfigMap named by every
+// caProvider, e.g. spec.provider.vault.caProvider or spec.provider.kubernetes.server.caProvider, by its type
+func caProviderReferences(value interface{}) []storeReference {
+	var refs []storeReference
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if caProvider, ok := nested.(map[string]interface{}); ok && key == "caProvider" {
+				providerType, _, _ := unstructured.NestedString(caProvider, "type")
+				name, _, _ := unstructured.NestedString(caProvider, "name")
+				namespace, _, _ := unstructured.NestedString(caProvider, "namespace")
+				switch providerType {
+				case "Secret":
+					refs = append(refs, storeReference{kind: graph.KindSecret, namespace: namespace, name: name})
+				case "ConfigMap":
+					refs = append(refs, storeReference{kind: graph.KindConfigMap, namespace: namespace, name: name})
+				}
+				continue
+			}
+			refs = append(refs, caProviderReferences(nested)...)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			refs = append(refs, caProviderReferences(nested)...)
+		}
+	}
+
+	return refs
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *ExternalSecretsReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSecretStoreReferences(t *testing.T) {
+	keySelector := func(name, key string) map[string]interface{} {
+		return map[string]interface{}{"name": name, "key": key}
+	}
+
+	tests := []struct {
+		name     string
+		provider map[string]interface{}
+		expected []storeReference
+	}{
+		{
+			name: "aws accessKeyIDSecretRef",
+			provider: map[string]interface{}{"aws": map[string]interface{}{
+				"service": "SecretsManager",
+				"auth": map[string]interface{}{"secretRef": map[string]interface{}{
+					"accessKeyIDSecretRef":     keySelector("aws-credentials", "access-key"),
+					"secretAccessKeySecretRef": keySelector("aws-credentials", "secret-key"),
+				}},
+			}},
+			expected: []storeReference{{kind: graph.KindSecret, name: "aws-credentials"}},
+		},
+		{
+			name: "vault tokenSecretRef and caProvider ConfigMap",
+			provider: map[string]interface{}{"vault": map[string]interface{}{
+				"server":     "https://vault:8200",
+				"auth":       map[string]interface{}{"tokenSecretRef": keySelector("vault-token", "token")},
+				"caProvider": map[string]interface{}{"type": "ConfigMap", "name": "vault-ca", "key": "ca.crt"},
+			}},
+			expected: []storeReference{
+				{kind: graph.KindSecret, name: "vault-token"},
+				{kind: graph.KindConfigMap, name: "vault-ca"},
+			},
+		},
+		{
+			name: "doppler dopplerToken",
+			provider: map[string]interface{}{"doppler": map[string]interface{}{
+				"auth": map[string]interface{}{"secretRef": map[string]interface{}{
+					"dopplerToken": keySelector("doppler-token", "token"),
+				}},
+			}},
+			expected: []storeReference{{kind: graph.KindSecret, name: "doppler-token"}},
+		},
+		{
+			name: "oracle privatekey and fingerprint",
+			provider: map[string]interface{}{"oracle": map[string]interface{}{
+				"region": "eu-frankfurt-1",
+				"auth": map[string]interface{}{
+					"user":    "ocid1.user",
+					"tenancy": "ocid1.tenancy",
+					"secretRef": map[string]interface{}{
+						"privatekey":  keySelector("oracle-key", "privateKey"),
+						"fingerprint": keySelector("oracle-fingerprint", "fingerprint"),
+					},
+				},
+			}},
+			expected: []storeReference{
+				{kind: graph.KindSecret, name: "oracle-key"},
+				{kind: graph.KindSecret, name: "oracle-fingerprint"},
+			},
+		},
+		{
+			name: "azurekv clientId and clientSecret",
+			provider: map[string]interface{}{"azurekv": map[string]interface{}{
+				"tenantId": "tenant",
+				"vaultUrl": "https://vault.azure.net",
+				"authSecretRef": map[string]interface{}{
+					"clientId":     keySelector("azure-client", "client-id"),
+					"clientSecret": keySelector("azure-secret", "client-secret"),
+				},
+			}},
+			expected: []storeReference{
+				{kind: graph.KindSecret, name: "azure-client"},
+				{kind: graph.KindSecret, name: "azure-secret"},
+			},
+		},
+		{
+			name: "akeyless accessID",
+			provider: map[string]interface{}{"akeyless": map[string]interface{}{
+				"akeylessGWApiURL": "https://api.akeyless.io",
+				"authSecretRef": map[string]interface{}{"secretRef": map[string]interface{}{
+					"accessID":        keySelector("akeyless-access", "access-id"),
+					"accessType":      keySelector("akeyless-access", "access-type"),
+					"accessTypeParam": keySelector("akeyless-access", "access-type-param"),
+				}},
+			}},
+			expected: []storeReference{{kind: graph.KindSecret, name: "akeyless-access"}},
+		},
+		{
+			name: "kubernetes bearerToken, clientCert and server caProvider Secret",
+			provider: map[string]interface{}{"kubernetes": map[string]interface{}{
+				"remoteNamespace": "default",
+				"server": map[string]interface{}{
+					"url":        "https://kubernetes.default",
+					"caProvider": map[string]interface{}{"type": "Secret", "name": "cluster-ca", "key": "ca.crt"},
+				},
+				"auth": map[string]interface{}{
+					"token": map[string]interface{}{"bearerToken": keySelector("kubernetes-token", "token")},
+					"cert": map[string]interface{}{
+						"clientCert": keySelector("kubernetes-client", "tls.crt"),
+						"clientKey":  keySelector("kubernetes-client", "tls.key"),
+					},
+				},
+			}},
+			expected: []storeReference{
+				{kind: graph.KindSecret, name: "kubernetes-token"},
+				{kind: graph.KindSecret, name: "kubernetes-client"},
+				{kind: graph.KindSecret, name: "cluster-ca"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretStore := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "external-secrets.io/v1",
+				"kind":       "SecretStore",
+				"metadata":   map[string]interface{}{"name": "store", "namespace": "default"},
+				"spec":       map[string]interface{}{"provider": tt.provider},
+			}}
+
+			refs := secretStoreReferences(secretStore)
+			for _, expected := range tt.expected {
+				if !slices.Contains(refs, expected) {
+					t.Errorf("expected %s %s in the references, got %v", expected.kind, expected.name, refs)
+				}
+			}
+			for _, ref := range refs {
+				if !slices.Contains(tt.expected, ref) {
+					t.Errorf("unexpected %s %s in the references", ref.kind, ref.name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"context"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GatewayResourceType represents a valid Gateway API resource type
+type GatewayResourceType string
+
+const (
+	GatewayResourceTypeGateway          GatewayResourceType = "Gateway"
+	GatewayResourceTypeBackendTLSPolicy GatewayResourceType = "BackendTLSPolicy"
+)
+
+// gatewayGroup is the API group of the Gateway API
+const gatewayGroup = "gateway.networking.k8s.io"
+
+// GatewayReferenceFinder finds the certificates and CA certificates that Gateways and BackendTLSPolicies
+// reference in Secrets and ConfigMaps. References of a Gateway into another namespace only count when a
+// ReferenceGrant allows them.
+type GatewayReferenceFinder struct {
+	client.Client
+	resourceType GatewayResourceType
+	servedKinds  ServedKinds
+}
+
+// NewGatewayReferenceFinder creates a new GatewayReferenceFinder for the given resource type
+func NewGatewayReferenceFinder(c client.Client, resourceType GatewayResourceType, servedKinds ServedKinds) *GatewayReferenceFinder {
+	return &GatewayReferenceFinder{
+		Client:       c,
+		resourceType: resourceType,
+		servedKinds:  servedKinds,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by the Gateway API resources to the graph
+func (f *GatewayReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	switch f.resourceType {
+	case GatewayResourceTypeGateway:
+		return f.collectGatewayReferences(ctx, c, g)
+	case GatewayResourceTypeBackendTLSPolicy:
+		return f.collectBackendTLSPolicyReferences(ctx, c, namespace, g)
+	}
+
+	return nil
+}
+
+// collectGatewayReferences adds the certificates and CA certificates referenced by Gateways to the graph.
+// Gateways can reference Secrets and ConfigMaps in other namespaces, so they are listed across the cluster.
+// A reference into another namespace only counts if a ReferenceGrant in that namespace allows it.
+func (f *GatewayReferenceFinder) collectGatewayReferences(ctx context.Context, c client.Client, g *graph.ReferenceGraph) error {
+	gateways, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: gatewayGroup, Kind: "Gateway"})
+	if err != nil || gateways == nil {
+		return err
+	}
+	grants, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: gatewayGroup, Kind: "ReferenceGrant"})
+	if err != nil {
+		return err
+	}
+
+	for i := range gateways.Items {
+		gateway := &gateways.Items[i]
+		for _, ref := range gatewayObjectReferences(gateway) {
+			namespace := ref.namespace
+			if namespace == "" {
+				namespace = gateway.GetNamespace()
+			}
+			if namespace != gateway.GetNamespace() && !referenceGranted(grants, gateway, ref, namespace) {
+				continue
+			}
+			if ref.group == "" && (ref.kind == graph.KindSecret || ref.kind == graph.KindConfigMap) {
+				g.AddReference(gateway, ref.kind, namespace, ref.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectBackendTLSPolicyReferences adds the CA certificates referenced by BackendTLSPolicies to the graph.
+// BackendTLSPolicy only references resources in its own namespace.
+func (f *GatewayReferenceFinder) collectBackendTLSPolicyReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	policies, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: gatewayGroup, Kind: "BackendTLSPolicy"}, client.InNamespace(namespace))
+	if err != nil || policies == nil {
+		return err
+	}
+
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		// Check spec.validation.caCertificateRefs
+		refs, _, _ := unstructured.NestedSlice(policy.Object, "spec", "validation", "caCertificateRefs")
+		for _, ref := range toObjectReferences(refs, "") {
+			if ref.group == "" && (ref.kind == graph.KindSecret || ref.kind == graph.KindConfigMap) {
+				g.AddReference(policy, ref.kind, policy.GetNamespace(), ref.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// objectReference is a Gateway API reference to another object
+type objectReference struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// gatewayObjectReferences returns the certificate and CA certificate references of a Gateway
+func gatewayObjectReferences(gateway *unstructured.Unstructured) []objectReference {
+	var refs []objectReference
+
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	for _, listener := range listeners {
+		listenerObject, ok := listener.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Check spec.listeners[].tls.certificateRefs, which default to Secrets
+		certificateRefs, _, _ := unstructured.NestedSlice(listenerObject, "tls", "certificateRefs")
+		refs = append(refs, toObjectReferences(certificateRefs, graph.KindSecret)...)
+		// Check spec.listeners[].tls.frontendValidation.caCertificateRefs
+		caCertificateRefs, _, _ := unstructured.NestedSlice(listenerObject, "tls", "frontendValidation", "caCertificateRefs")
+		refs = append(refs, toObjectReferences(caCertificateRefs, "")...)
+	}
+
+	// Check spec.tls.frontend.default.validation.caCertificateRefs
+	defaultRefs, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "tls", "frontend", "default", "validation", "caCertificateRefs")
+	refs = append(refs, toObjectReferences(defaultRefs, "")...)
+
+	// Check spec.tls.frontend.perPort[].tls.validation.caCertificateRefs
+	perPort, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "tls", "frontend", "perPort")
+	for _, port := range perPort {
+		portObject, ok := port.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		portRefs, _, _ := unstructured.NestedSlice(portObject, "tls", "validation", "caCertificateRefs")
+		refs = append(refs, toObjectReferences(portRefs, "")...)
+	}
+
+	// Check spec.tls.backend.clientCertificateRef, which defaults to a Secret
+	if clientCertificateRef, found, _ := unstructured.NestedMap(gateway.Object, "spec", "tls", "backend", "clientCertificateRef"); found {
+		refs = append(refs, toObjectReferences([]interface{}{clientCertificateRef}, graph.KindSecret)...)
+	}
+
+	return refs
+}
+
+// gatewayReferencedNamespaces returns the namespaces named by the references of a Gateway
+func gatewayReferencedNamespaces(gateway *unstructured.Unstructured) []string {
+	var namespaces []string
+	for _, ref := range gatewayObjectReferences(gateway) {
+		namespaces = append(namespaces, ref.namespace)
+	}
+	return namespaces
+}
+
+// referenceGrantFromNamespaces returns the namespaces a ReferenceGrant allows references from
+func referenceGrantFromNamespaces(grant *unstructured.Unstructured) []string {
+	var namespaces []string
+	from, _, _ := unstructured.NestedSlice(grant.Object, "spec", "from")
+	for _, source := range toObjectReferences(from, "") {
+		namespaces = append(namespaces, source.namespace)
+	}
+	return namespaces
+}
+
+// toObjectReferences converts unstructured references into objectReferences, using defaultKind when the kind is unset
+func toObjectReferences(refs []interface{}, defaultKind string) []objectReference {
+	result := make([]objectReference, 0, len(refs))
+	for _, ref := range refs {
+		refObject, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(refObject, "group")
+		kind, _, _ := unstructured.NestedString(refObject, "kind")
+		namespace, _, _ := unstructured.NestedString(refObject, "namespace")
+		name, _, _ := unstructured.NestedString(refObject, "name")
+		if kind == "" {
+			kind = defaultKind
+		}
+		result = append(result, objectReference{group: group, kind: kind, namespace: namespace, name: name})
+	}
+	return result
+}
+
+// referenceGranted checks if a ReferenceGrant in the target namespace allows the Gateway to reference the target
+func referenceGranted(grants *unstructured.UnstructuredList, gateway *unstructured.Unstructured, ref objectReference, namespace string) bool {
+	if grants == nil {
+		return false
+	}
+
+	for _, grant := range grants.Items {
+		if grant.GetNamespace() != namespace {
+			continue
+		}
+
+		from, _, _ := unstructured.NestedSlice(grant.Object, "spec", "from")
+		fromAllowed := false
+		for _, source := range toObjectReferences(from, "") {
+			if source.group == gatewayGroup && source.kind == "Gateway" && source.namespace == gateway.GetNamespace() {
+				fromAllowed = true
+				break
+			}
+		}
+		if !fromAllowed {
+			continue
+		}
+
+		to, _, _ := unstructured.NestedSlice(grant.Object, "spec", "to")
+		for _, target := range toObjectReferences(to, "") {
+			if target.group == ref.group && target.kind == ref.kind && (target.name == "" || target.name == ref.name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *GatewayReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
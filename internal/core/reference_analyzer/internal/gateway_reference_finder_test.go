@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestReferenceGranted(t *testing.T) {
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "gateways"},
+	}}
+	secret := objectReference{kind: graph.KindSecret, namespace: "certificates", name: "web-tls"}
+
+	referenceGrant := func(namespace string, from, to []interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1beta1",
+			"kind":       "ReferenceGrant",
+			"metadata":   map[string]interface{}{"name": "allow-gateways", "namespace": namespace},
+			"spec":       map[string]interface{}{"from": from, "to": to},
+		}}
+	}
+	fromGateways := []interface{}{
+		map[string]interface{}{"group": gatewayGroup, "kind": "Gateway", "namespace": "gateways"},
+	}
+	toSecrets := []interface{}{
+		map[string]interface{}{"group": "", "kind": "Secret"},
+	}
+
+	tests := []struct {
+		name     string
+		grants   []unstructured.Unstructured
+		expected bool
+	}{
+		{
+			name:     "no ReferenceGrants",
+			expected: false,
+		},
+		{
+			name:     "grant for all Secrets",
+			grants:   []unstructured.Unstructured{referenceGrant("certificates", fromGateways, toSecrets)},
+			expected: true,
+		},
+		{
+			name: "grant for the named Secret",
+			grants: []unstructured.Unstructured{referenceGrant("certificates", fromGateways, []interface{}{
+				map[string]interface{}{"group": "", "kind": "Secret", "name": "web-tls"},
+			})},
+			expected: true,
+		},
+		{
+			name: "grant for another named Secret",
+			grants: []unstructured.Unstructured{referenceGrant("certificates", fromGateways, []interface{}{
+				map[string]interface{}{"group": "", "kind": "Secret", "name": "api-tls"},
+			})},
+			expected: false,
+		},
+		{
+			name: "grant for ConfigMaps",
+			grants: []unstructured.Unstructured{referenceGrant("certificates", fromGateways, []interface{}{
+				map[string]interface{}{"group": "", "kind": "ConfigMap"},
+			})},
+			expected: false,
+		},
+		{
+			name:     "grant in another namespace than the Secret",
+			grants:   []unstructured.Unstructured{referenceGrant("gateways", fromGateways, toSecrets)},
+			expected: false,
+		},
+		{
+			name: "grant from another namespace",
+			grants: []unstructured.Unstructured{referenceGrant("certificates", []interface{}{
+				map[string]interface{}{"group": gatewayGroup, "kind": "Gateway", "namespace": "other"},
+			}, toSecrets)},
+			expected: false,
+		},
+		{
+			name: "grant from HTTPRoutes",
+			grants: []unstructured.Unstructured{referenceGrant("certificates", []interface{}{
+				map[string]interface{}{"group": gatewayGroup, "kind": "HTTPRoute", "namespace": "gateways"},
+			}, toSecrets)},
+			expected: false,
+		},
+		{
+			name: "one of several grants matches",
+			grants: []unstructured.Unstructured{
+				referenceGrant("certificates", fromGateways, []interface{}{
+					map[string]interface{}{"group": "", "kind": "ConfigMap"},
+				}),
+				referenceGrant("certificates", append([]interface{}{
+					map[string]interface{}{"group": gatewayGroup, "kind": "Gateway", "namespace": "other"},
+				}, fromGateways...), toSecrets),
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var grants *unstructured.UnstructuredList
+			if tt.grants != nil {
+				grants = &unstructured.UnstructuredList{Items: tt.grants}
+			}
+
+			if granted := referenceGranted(grants, gateway, secret, "certificates"); granted != tt.expected {
+				t.Errorf("expected granted: %v, got %v", tt.expected, granted)
+			}
+		})
+	}
+}
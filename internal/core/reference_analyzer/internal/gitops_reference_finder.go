@@ -0,0 +1,180 @@
+package internal
+
+import (
+	"context"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GitOpsResourceType represents a valid Flux resource type, or Argo CD
+type GitOpsResourceType string
+
+const (
+	GitOpsResourceTypeGitRepository  GitOpsResourceType = "GitRepository"
+	GitOpsResourceTypeHelmRepository GitOpsResourceType = "HelmRepository"
+	GitOpsResourceTypeOCIRepository  GitOpsResourceType = "OCIRepository"
+	GitOpsResourceTypeKustomization  GitOpsResourceType = "Kustomization"
+	GitOpsResourceTypeHelmRelease    GitOpsResourceType = "HelmRelease"
+	// GitOpsResourceTypeArgoCD is not a kind; it stands for the Argo CD instance consuming the
+	// repository and cluster Secrets of its namespace
+	GitOpsResourceTypeArgoCD GitOpsResourceType = "ArgoCD"
+)
+
+const (
+	// fluxSourceGroup is the API group of the Flux sources
+	fluxSourceGroup = "source.toolkit.fluxcd.io"
+	// fluxKustomizeGroup is the API group of the Flux Kustomization
+	fluxKustomizeGroup = "kustomize.toolkit.fluxcd.io"
+	// fluxHelmGroup is the API group of the Flux HelmRelease
+	fluxHelmGroup = "helm.toolkit.fluxcd.io"
+)
+
+const (
+	// ArgoCDSecretTypeLabel is set on the repository, repository credential and cluster Secrets read by Argo CD
+	ArgoCDSecretTypeLabel = "argocd.argoproj.io/secret-type"
+	// ArgoCDPartOfValue is the value of the app.kubernetes.io/part-of label of the Argo CD components
+	ArgoCDPartOfValue = "argocd"
+)
+
+// partOfLabel is the well-known label naming the application a component is part of
+const partOfLabel = "app.kubernetes.io/part-of"
+
+// GitOpsReferenceFinder finds the credentials of Flux sources, the decryption keys, kubeconfigs and values that Flux
+// Kustomizations and HelmReleases read from Secrets and ConfigMaps, and the repository and cluster Secrets
+// consumed by Argo CD.
+type GitOpsReferenceFinder struct {
+	client.Client
+	resourceType GitOpsResourceType
+	servedKinds  ServedKinds
+}
+
+// NewGitOpsReferenceFinder creates a new GitOpsReferenceFinder for the given resource type
+func NewGitOpsReferenceFinder(c client.Client, resourceType GitOpsResourceType, servedKinds ServedKinds) *GitOpsReferenceFinder {
+	return &GitOpsReferenceFinder{
+		Client:       c,
+		resourceType: resourceType,
+		servedKinds:  servedKinds,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by the Flux resources, or consumed by Argo CD, to the graph
+func (f *GitOpsReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	switch f.resourceType {
+	case GitOpsResourceTypeGitRepository, GitOpsResourceTypeHelmRepository, GitOpsResourceTypeOCIRepository:
+		sources, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: fluxSourceGroup, Kind: string(f.resourceType)}, client.InNamespace(namespace))
+		if err != nil || sources == nil {
+			return err
+		}
+		for i := range sources.Items {
+			source := &sources.Items[i]
+			// Check spec.secretRef, spec.certSecretRef, spec.proxySecretRef and spec.verify.secretRef
+			spec, _, _ := unstructured.NestedMap(source.Object, "spec")
+			for _, ref := range nestedSecretReferences(spec) {
+				g.AddSecretReference(source, source.GetNamespace(), ref.name)
+			}
+		}
+
+	case GitOpsResourceTypeKustomization:
+		kustomizations, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: fluxKustomizeGroup, Kind: "Kustomization"}, client.InNamespace(namespace))
+		if err != nil || kustomizations == nil {
+			return err
+		}
+		for i := range kustomizations.Items {
+			kustomization := &kustomizations.Items[i]
+			// Check spec.decryption.secretRef and spec.kubeConfig.secretRef
+			decryptionSecretName, _, _ := unstructured.NestedString(kustomization.Object, "spec", "decryption", "secretRef", "name")
+			g.AddSecretReference(kustomization, kustomization.GetNamespace(), decryptionSecretName)
+			kubeConfigSecretName, _, _ := unstructured.NestedString(kustomization.Object, "spec", "kubeConfig", "secretRef", "name")
+			g.AddSecretReference(kustomization, kustomization.GetNamespace(), kubeConfigSecretName)
+			// Check spec.postBuild.substituteFrom
+			substituteFrom, _, _ := unstructured.NestedSlice(kustomization.Object, "spec", "postBuild", "substituteFrom")
+			collectKindReferences(kustomization, substituteFrom, g)
+		}
+
+	case GitOpsResourceTypeHelmRelease:
+		helmReleases, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: fluxHelmGroup, Kind: "HelmRelease"}, client.InNamespace(namespace))
+		if err != nil || helmReleases == nil {
+			return err
+		}
+		for i := range helmReleases.Items {
+			helmRelease := &helmReleases.Items[i]
+			// Check spec.valuesFrom
+			valuesFrom, _, _ := unstructured.NestedSlice(helmRelease.Object, "spec", "valuesFrom")
+			collectKindReferences(helmRelease, valuesFrom, g)
+			// Check spec.kubeConfig.secretRef
+			secretName, _, _ := unstructured.NestedString(helmRelease.Object, "spec", "kubeConfig", "secretRef", "name")
+			g.AddSecretReference(helmRelease, helmRelease.GetNamespace(), secretName)
+		}
+
+	case GitOpsResourceTypeArgoCD:
+		return f.collectArgoCDReferences(ctx, c, namespace, g)
+	}
+
+	return nil
+}
+
+// collectArgoCDReferences adds the Secrets labelled with argocd.argoproj.io/secret-type to the graph, as consumed by
+// the Argo CD instance in the namespace. Argo CD finds these Secrets by label rather than by name, so they are
+// referenced by the StatefulSets and Deployments labelled app.kubernetes.io/part-of=argocd. Without an Argo CD
+// instance in the namespace the Secrets are not read by anyone.
+func (f *GitOpsReferenceFinder) collectArgoCDReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	var consumers []client.Object
+
+	statefulSetList := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSetList, client.InNamespace(namespace), client.MatchingLabels{partOfLabel: ArgoCDPartOfValue}); err != nil {
+		return err
+	}
+	for i := range statefulSetList.Items {
+		consumers = append(consumers, &statefulSetList.Items[i])
+	}
+
+	deploymentList := &appsv1.DeploymentList{}
+	if err := c.List(ctx, deploymentList, client.InNamespace(namespace), client.MatchingLabels{partOfLabel: ArgoCDPartOfValue}); err != nil {
+		return err
+	}
+	for i := range deploymentList.Items {
+		consumers = append(consumers, &deploymentList.Items[i])
+	}
+
+	if len(consumers) == 0 {
+		return nil
+	}
+
+	secretList := &corev1.SecretList{}
+	if err := c.List(ctx, secretList, client.InNamespace(namespace), client.HasLabels{ArgoCDSecretTypeLabel}); err != nil {
+		return err
+	}
+	for _, secret := range secretList.Items {
+		for _, consumer := range consumers {
+			g.AddSecretReference(consumer, namespace, secret.Name)
+		}
+	}
+
+	return nil
+}
+
+// collectKindReferences adds the Secrets and ConfigMaps of a Flux list of references with a kind and a name,
+// such as HelmRelease spec.valuesFrom or Kustomization spec.postBuild.substituteFrom, to the graph
+func collectKindReferences(consumer *unstructured.Unstructured, refs []interface{}, g *graph.ReferenceGraph) {
+	for _, ref := range refs {
+		refObject, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(refObject, "kind")
+		name, _, _ := unstructured.NestedString(refObject, "name")
+		if kind == graph.KindSecret || kind == graph.KindConfigMap {
+			g.AddReference(consumer, kind, consumer.GetNamespace(), name)
+		}
+	}
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *GitOpsReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
@@ -2,57 +2,130 @@ package internal
 
 import (
 	"context"
+	"strings"
 
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// IngressReferenceFinder finds references to Secrets and ConfigMaps in Ingress resources
+// AnnotationReference describes an annotation whose value names a Secret or ConfigMap,
+// either as "name" in the namespace of the annotated resource or as "namespace/name"
+type AnnotationReference struct {
+	// Annotation is the annotation key
+	Annotation string
+	// Kind is the kind of the referenced resource (Secret or ConfigMap)
+	Kind string
+}
+
+// IngressReferenceFinder finds references to Secrets and ConfigMaps in Ingress resources.
+// Annotations can reference resources in other namespaces, so Ingresses are listed across the cluster.
 type IngressReferenceFinder struct {
 	client.Client
+	annotationReferences []AnnotationReference
 }
 
-// NewIngressReferenceFinder creates a new IngressReferenceFinder
-func NewIngressReferenceFinder(c client.Client) *IngressReferenceFinder {
+// NewIngressReferenceFinder creates a new IngressReferenceFinder that also follows the given annotation references
+func NewIngressReferenceFinder(c client.Client, annotationReferences []AnnotationReference) *IngressReferenceFinder {
 	return &IngressReferenceFinder{
-		Client: c,
+		Client:               c,
+		annotationReferences: annotationReferences,
 	}
 }
 
-// FindSecretReferences finds all Ingresses that reference the given Secret
-func (f *IngressReferenceFinder) FindSecretReferences(ctx context.Context, c client.Client, secretName, namespace string) ([]client.Object, error) {
-	var results []client.Object
-
+// CollectReferences adds every Secret and ConfigMap referenced by the Ingresses to the graph.
+// The namespace is ignored; references into all namespaces are recorded.
+func (f *IngressReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
 	ingressList := &networkingv1.IngressList{}
-	if err := c.List(ctx, ingressList, client.InNamespace(namespace)); err != nil {
-		return nil, err
+	if err := c.List(ctx, ingressList); err != nil {
+		return err
 	}
 
 	for i := range ingressList.Items {
-		ingress := &ingressList.Items[i]
-		if f.ingressReferencesSecret(ingress, secretName) {
-			results = append(results, ingress)
-		}
+		f.collectIngressSecretReferences(&ingressList.Items[i], g)
+		f.collectIngressBackendReferences(&ingressList.Items[i], g)
+		f.collectIngressAnnotationReferences(&ingressList.Items[i], g)
 	}
 
-	return results, nil
+	return nil
 }
 
-// ingressReferencesSecret checks if an Ingress references the given secret
-func (f *IngressReferenceFinder) ingressReferencesSecret(ingress *networkingv1.Ingress, secretName string) bool {
+// collectIngressSecretReferences adds the TLS Secrets referenced by an Ingress to the graph
+func (f *IngressReferenceFinder) collectIngressSecretReferences(ingress *networkingv1.Ingress, g *graph.ReferenceGraph) {
 	// Check spec.tls[].secretName (for TLS secrets)
 	for _, tls := range ingress.Spec.TLS {
-		if tls.SecretName == secretName {
-			return true
+		g.AddSecretReference(ingress, ingress.Namespace, tls.SecretName)
+	}
+}
+
+// collectIngressBackendReferences adds the Secrets and ConfigMaps used as resource backends of an Ingress to the graph
+func (f *IngressReferenceFinder) collectIngressBackendReferences(ingress *networkingv1.Ingress, g *graph.ReferenceGraph) {
+	// Check spec.defaultBackend.resource
+	if ingress.Spec.DefaultBackend != nil {
+		addResourceBackendReference(ingress, ingress.Spec.DefaultBackend.Resource, g)
+	}
+
+	// Check spec.rules[].http.paths[].backend.resource
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			addResourceBackendReference(ingress, path.Backend.Resource, g)
+		}
+	}
+}
+
+// addResourceBackendReference adds a resource backend to the graph if it is a Secret or ConfigMap.
+// Backends of other kinds, such as storage buckets of a cloud provider, are ignored.
+func addResourceBackendReference(ingress *networkingv1.Ingress, resource *corev1.TypedLocalObjectReference, g *graph.ReferenceGraph) {
+	if resource == nil || (resource.APIGroup != nil && *resource.APIGroup != "") {
+		return
+	}
+	if resource.Kind == graph.KindSecret || resource.Kind == graph.KindConfigMap {
+		g.AddReference(ingress, resource.Kind, ingress.Namespace, resource.Name)
+	}
+}
+
+// collectIngressAnnotationReferences adds the Secrets and ConfigMaps named in the annotations of an Ingress to the graph
+func (f *IngressReferenceFinder) collectIngressAnnotationReferences(ingress *networkingv1.Ingress, g *graph.ReferenceGraph) {
+	for _, reference := range f.annotationReferences {
+		value, exists := ingress.Annotations[reference.Annotation]
+		if !exists {
+			continue
+		}
+		if namespace, name, ok := splitNamespacedName(value, ingress.Namespace); ok {
+			g.AddReference(ingress, reference.Kind, namespace, name)
 		}
 	}
+}
 
-	return false
+// ingressReferencedNamespaces returns the namespaces named by the annotation references of an Ingress
+func ingressReferencedNamespaces(ingress *networkingv1.Ingress, annotationReferences []AnnotationReference) []string {
+	var namespaces []string
+	for _, reference := range annotationReferences {
+		if value, exists := ingress.Annotations[reference.Annotation]; exists {
+			if namespace, _, ok := splitNamespacedName(value, ingress.Namespace); ok {
+				namespaces = append(namespaces, namespace)
+			}
+		}
+	}
+	return namespaces
 }
 
-// Ingress does not reference ConfigMaps. This method is implemented to satisfy the ReferenceFinderStrategy interface.
-func (f *IngressReferenceFinder) FindConfigMapReferences(ctx context.Context, c client.Client, configMapName, namespace string) ([]client.Object, error) {
-	return nil, nil
+// splitNamespacedName splits a "namespace/name" reference. A plain "name" is in the default namespace.
+// It reports false for malformed values, with an empty namespace or name or more than one "/".
+func splitNamespacedName(value, defaultNamespace string) (namespace, name string, ok bool) {
+	value = strings.TrimSpace(value)
+	namespace, name, found := strings.Cut(value, "/")
+	if !found {
+		namespace, name = defaultNamespace, value
+	}
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", false
+	}
+	return namespace, name, true
 }
 
 // GetResourceType returns the Kubernetes resource type this strategy handles
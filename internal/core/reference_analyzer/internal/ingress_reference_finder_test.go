@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSplitNamespacedName(t *testing.T) {
+	tests := []struct {
+		name              string
+		value             string
+		expectedNamespace string
+		expectedName      string
+		expectedOk        bool
+	}{
+		{name: "plain name", value: "basic-auth", expectedNamespace: "default", expectedName: "basic-auth", expectedOk: true},
+		{name: "cross-namespace", value: "auth/basic-auth", expectedNamespace: "auth", expectedName: "basic-auth", expectedOk: true},
+		{name: "surrounding spaces", value: " auth/basic-auth ", expectedNamespace: "auth", expectedName: "basic-auth", expectedOk: true},
+		{name: "empty value", value: "", expectedOk: false},
+		{name: "empty namespace", value: "/basic-auth", expectedOk: false},
+		{name: "empty name", value: "auth/", expectedOk: false},
+		{name: "more than one slash", value: "auth/basic/auth", expectedOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, name, ok := splitNamespacedName(tt.value, "default")
+			if ok != tt.expectedOk || namespace != tt.expectedNamespace || name != tt.expectedName {
+				t.Errorf("expected (%q, %q, %v), got (%q, %q, %v)",
+					tt.expectedNamespace, tt.expectedName, tt.expectedOk, namespace, name, ok)
+			}
+		})
+	}
+}
+
+func TestCollectReferencesIngressAnnotations(t *testing.T) {
+	annotationReferences := []AnnotationReference{
+		{Annotation: "nginx.ingress.kubernetes.io/auth-secret", Kind: graph.KindSecret},
+		{Annotation: "nginx.ingress.kubernetes.io/custom-headers", Kind: graph.KindConfigMap},
+	}
+
+	tests := []struct {
+		name               string
+		annotations        map[string]string
+		kind               string
+		namespace          string
+		target             string
+		referenced         bool
+		expectedNamespaces []string
+	}{
+		{
+			name:        "Secret in the namespace of the Ingress",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/auth-secret": "basic-auth"},
+			kind:        graph.KindSecret, namespace: "web", target: "basic-auth", referenced: true,
+		},
+		{
+			name:        "cross-namespace Secret",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/auth-secret": "auth/basic-auth"},
+			kind:        graph.KindSecret, namespace: "auth", target: "basic-auth", referenced: true,
+			expectedNamespaces: []string{"auth"},
+		},
+		{
+			name:        "cross-namespace ConfigMap",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/custom-headers": "shared/headers"},
+			kind:        graph.KindConfigMap, namespace: "shared", target: "headers", referenced: true,
+			expectedNamespaces: []string{"shared"},
+		},
+		{
+			name:        "annotation kind is not mixed up",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/custom-headers": "headers"},
+			kind:        graph.KindSecret, namespace: "web", target: "headers", referenced: false,
+		},
+		{
+			name:        "malformed value",
+			annotations: map[string]string{"nginx.ingress.kubernetes.io/auth-secret": "auth/basic/auth"},
+			kind:        graph.KindSecret, namespace: "auth", target: "basic/auth", referenced: false,
+		},
+		{
+			name:        "unlisted annotation",
+			annotations: map[string]string{"example.com/auth-secret": "basic-auth"},
+			kind:        graph.KindSecret, namespace: "web", target: "basic-auth", referenced: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "web", Annotations: tt.annotations},
+			}
+			c := fake.NewClientBuilder().WithObjects(ingress).Build()
+			finder := NewIngressReferenceFinder(c, annotationReferences)
+
+			g := graph.NewReferenceGraph()
+			if err := finder.CollectReferences(context.Background(), c, "web", g); err != nil {
+				t.Fatalf("CollectReferences returned an error: %v", err)
+			}
+
+			if referenced := g.IsReferenced(tt.kind, tt.namespace, tt.target); referenced != tt.referenced {
+				t.Errorf("expected %s %s/%s to be referenced: %v, got %v", tt.kind, tt.namespace, tt.target, tt.referenced, referenced)
+			}
+
+			namespaces, err := ReferencedNamespaces(context.Background(), c, ingress, annotationReferences)
+			if err != nil {
+				t.Fatalf("ReferencedNamespaces returned an error: %v", err)
+			}
+			if !slices.Equal(namespaces, tt.expectedNamespaces) {
+				t.Errorf("expected referenced namespaces %v, got %v", tt.expectedNamespaces, namespaces)
+			}
+		})
+	}
+}
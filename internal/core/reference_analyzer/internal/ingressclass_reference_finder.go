@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IngressClassReferenceFinder finds references to Secrets and ConfigMaps in IngressClass resources
+type IngressClassReferenceFinder struct {
+	client.Client
+}
+
+// NewIngressClassReferenceFinder creates a new IngressClassReferenceFinder
+func NewIngressClassReferenceFinder(c client.Client) *IngressClassReferenceFinder {
+	return &IngressClassReferenceFinder{
+		Client: c,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by the IngressClasses to the graph.
+// IngressClasses are cluster-scoped, so the namespace is ignored and references into all namespaces are recorded.
+func (f *IngressClassReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	ingressClassList := &networkingv1.IngressClassList{}
+	if err := c.List(ctx, ingressClassList); err != nil {
+		return err
+	}
+
+	for i := range ingressClassList.Items {
+		f.collectIngressClassParametersReference(&ingressClassList.Items[i], g)
+	}
+
+	return nil
+}
+
+// collectIngressClassParametersReference adds the Secret or ConfigMap holding the parameters of an IngressClass to the graph.
+// Secrets and ConfigMaps are namespaced, so only parameters with the Namespace scope can reference them.
+func (f *IngressClassReferenceFinder) collectIngressClassParametersReference(ingressClass *networkingv1.IngressClass, g *graph.ReferenceGraph) {
+	parameters := ingressClass.Spec.Parameters
+	if parameters == nil || parameters.Namespace == nil {
+		return
+	}
+	if parameters.APIGroup != nil && *parameters.APIGroup != "" {
+		return
+	}
+	if parameters.Scope != nil && *parameters.Scope != networkingv1.IngressClassParametersReferenceScopeNamespace {
+		return
+	}
+
+	// Check spec.parameters with kind ConfigMap or Secret
+	if parameters.Kind == graph.KindConfigMap || parameters.Kind == graph.KindSecret {
+		g.AddReference(ingressClass, parameters.Kind, *parameters.Namespace, parameters.Name)
+	}
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *IngressClassReferenceFinder) GetResourceType() string {
+	return "IngressClass"
+}
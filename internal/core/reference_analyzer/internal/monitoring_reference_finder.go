@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"context"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MonitoringResourceType represents a valid Prometheus Operator resource type
+type MonitoringResourceType string
+
+const (
+	MonitoringResourceTypePrometheus         MonitoringResourceType = "Prometheus"
+	MonitoringResourceTypeAlertmanager       MonitoringResourceType = "Alertmanager"
+	MonitoringResourceTypeAlertmanagerConfig MonitoringResourceType = "AlertmanagerConfig"
+	MonitoringResourceTypeServiceMonitor     MonitoringResourceType = "ServiceMonitor"
+	MonitoringResourceTypePodMonitor         MonitoringResourceType = "PodMonitor"
+	MonitoringResourceTypeProbe              MonitoringResourceType = "Probe"
+)
+
+// monitoringGroup is the API group of the Prometheus Operator
+const monitoringGroup = "monitoring.coreos.com"
+
+// MonitoringReferenceFinder finds the Secrets and ConfigMaps read by Prometheus Operator resources: the credentials
+// and TLS settings of scrape targets and receivers, the Secrets and ConfigMaps mounted into Prometheus and
+// Alertmanager, and the configuration Secret of Alertmanager.
+type MonitoringReferenceFinder struct {
+	client.Client
+	resourceType MonitoringResourceType
+	servedKinds  ServedKinds
+}
+
+// NewMonitoringReferenceFinder creates a new MonitoringReferenceFinder for the given resource type
+func NewMonitoringReferenceFinder(c client.Client, resourceType MonitoringResourceType, servedKinds ServedKinds) *MonitoringReferenceFinder {
+	return &MonitoringReferenceFinder{
+		Client:       c,
+		resourceType: resourceType,
+		servedKinds:  servedKinds,
+	}
+}
+
+// CollectReferences adds every Secret and ConfigMap referenced by the Prometheus Operator resources to the graph
+func (f *MonitoringReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	resources, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: monitoringGroup, Kind: string(f.resourceType)}, client.InNamespace(namespace))
+	if err != nil || resources == nil {
+		return err
+	}
+
+	for i := range resources.Items {
+		resource := &resources.Items[i]
+		spec, _, _ := unstructured.NestedMap(resource.Object, "spec")
+
+		// Check every SecretKeySelector and the Secret or ConfigMap sources of TLS and OAuth2 settings,
+		// e.g. spec.additionalScrapeConfigs, spec.endpoints[].basicAuth.password,
+		// spec.endpoints[].tlsConfig.ca.configMap or spec.receivers[].slackConfigs[].apiURL
+		collectKeySelectorReferences(resource, spec, g)
+
+		switch f.resourceType {
+		case MonitoringResourceTypePrometheus, MonitoringResourceTypeAlertmanager:
+			f.collectMountedReferences(resource, spec, g)
+		}
+
+		if f.resourceType == MonitoringResourceTypeAlertmanager {
+			// Check spec.configSecret, which defaults to alertmanager-<name>
+			configSecret, _, _ := unstructured.NestedString(spec, "configSecret")
+			if configSecret == "" {
+				configSecret = "alertmanager-" + resource.GetName()
+			}
+			g.AddSecretReference(resource, resource.GetNamespace(), configSecret)
+		}
+	}
+
+	return nil
+}
+
+// collectMountedReferences adds the Secrets and ConfigMaps listed in spec.secrets and spec.configMaps of a
+// Prometheus or Alertmanager, which are mounted into its Pods
+func (f *MonitoringReferenceFinder) collectMountedReferences(resource *unstructured.Unstructured, spec map[string]interface{}, g *graph.ReferenceGraph) {
+	secrets, _, _ := unstructured.NestedStringSlice(spec, "secrets")
+	for _, secret := range secrets {
+		g.AddSecretReference(resource, resource.GetNamespace(), secret)
+	}
+
+	configMaps, _, _ := unstructured.NestedStringSlice(spec, "configMaps")
+	for _, configMap := range configMaps {
+		g.AddConfigMapReference(resource, resource.GetNamespace(), configMap)
+	}
+}
+
+// collectKeySelectorReferences walks an unstructured value and adds the Secrets and ConfigMaps it selects keys of.
+// The Prometheus Operator references Secrets with SecretKeySelectors, objects with a "name" and a "key", and
+// wraps ConfigMaps and Secrets in "configMap" and "secret" fields where both are allowed.
+func collectKeySelectorReferences(resource *unstructured.Unstructured, value interface{}, g *graph.ReferenceGraph) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if selector, ok := nested.(map[string]interface{}); ok {
+				if kind, isSelector := keySelectorKind(key, selector); isSelector {
+					name, _, _ := unstructured.NestedString(selector, "name")
+					g.AddReference(resource, kind, resource.GetNamespace(), name)
+				}
+			}
+			collectKeySelectorReferences(resource, nested, g)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			collectKeySelectorReferences(resource, nested, g)
+		}
+	}
+}
+
+// keySelectorKind returns the kind selected by a selector under the given field, and whether the object is a
+// selector at all. The parent field decides the kind where a Secret or a ConfigMap is allowed, as in the ca and
+// cert of a SafeTLSConfig; any other object with a "name" and a "key" is a SecretKeySelector.
+func keySelectorKind(field string, selector map[string]interface{}) (string, bool) {
+	switch field {
+	case "configMap", "configMapKeyRef":
+		return graph.KindConfigMap, true
+	case "secret", "secretKeyRef":
+		return graph.KindSecret, true
+	}
+
+	_, hasKey := selector["key"]
+	return graph.KindSecret, hasKey
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *MonitoringReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCollectKeySelectorReferences(t *testing.T) {
+	serviceMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "ServiceMonitor",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "monitoring"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchExpressions": []interface{}{
+					map[string]interface{}{"key": "app", "operator": "In", "values": []interface{}{"app"}},
+				},
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"basicAuth": map[string]interface{}{
+						"username": map[string]interface{}{"name": "basic-auth", "key": "username"},
+						"password": map[string]interface{}{"name": "basic-auth", "key": "password"},
+					},
+					"tlsConfig": map[string]interface{}{
+						"ca":        map[string]interface{}{"configMap": map[string]interface{}{"name": "ca-bundle", "key": "ca.crt"}},
+						"cert":      map[string]interface{}{"secret": map[string]interface{}{"name": "client-tls", "key": "tls.crt"}},
+						"keySecret": map[string]interface{}{"name": "client-tls", "key": "tls.key"},
+					},
+				},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		kind       string
+		target     string
+		referenced bool
+	}{
+		{name: "basicAuth Secret", kind: graph.KindSecret, target: "basic-auth", referenced: true},
+		{name: "tlsConfig ConfigMap", kind: graph.KindConfigMap, target: "ca-bundle", referenced: true},
+		{name: "tlsConfig Secret", kind: graph.KindSecret, target: "client-tls", referenced: true},
+		{name: "tlsConfig ConfigMap is not a Secret", kind: graph.KindSecret, target: "ca-bundle", referenced: false},
+		{name: "label selector is not a reference", kind: graph.KindSecret, target: "app", referenced: false},
+	}
+
+	g := graph.NewReferenceGraph()
+	collectKeySelectorReferences(serviceMonitor, serviceMonitor.Object["spec"], g)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if referenced := g.IsReferenced(tt.kind, "monitoring", tt.target); referenced != tt.referenced {
+				t.Errorf("expected %s %s to be referenced: %v, got %v", tt.kind, tt.target, tt.referenced, referenced)
+			}
+		})
+	}
+}
+
+func TestCollectKeySelectorReferencesConfigMapCA(t *testing.T) {
+	prometheus := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "Prometheus",
+		"metadata":   map[string]interface{}{"name": "k8s", "namespace": "monitoring"},
+		"spec": map[string]interface{}{
+			"additionalScrapeConfigs": map[string]interface{}{"name": "additional-scrape-configs", "key": "prometheus-additional.yaml"},
+			"remoteWrite": []interface{}{
+				map[string]interface{}{
+					"url": "https://remote-write",
+					"tlsConfig": map[string]interface{}{
+						"ca": map[string]interface{}{"configMap": map[string]interface{}{"name": "remote-write-ca", "key": "ca.crt"}},
+					},
+				},
+			},
+			"web": map[string]interface{}{
+				"tlsConfig": map[string]interface{}{
+					"client_ca": map[string]interface{}{"configMap": map[string]interface{}{"name": "web-client-ca", "key": "ca.crt"}},
+					"cert":      map[string]interface{}{"secret": map[string]interface{}{"name": "web-tls", "key": "tls.crt"}},
+					"keySecret": map[string]interface{}{"name": "web-tls", "key": "tls.key"},
+				},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		kind       string
+		target     string
+		referenced bool
+	}{
+		{name: "additionalScrapeConfigs Secret", kind: graph.KindSecret, target: "additional-scrape-configs", referenced: true},
+		{name: "remoteWrite ConfigMap CA", kind: graph.KindConfigMap, target: "remote-write-ca", referenced: true},
+		{name: "remoteWrite ConfigMap CA is not a Secret", kind: graph.KindSecret, target: "remote-write-ca", referenced: false},
+		{name: "web client ConfigMap CA", kind: graph.KindConfigMap, target: "web-client-ca", referenced: true},
+		{name: "web client ConfigMap CA is not a Secret", kind: graph.KindSecret, target: "web-client-ca", referenced: false},
+		{name: "web Secret certificate", kind: graph.KindSecret, target: "web-tls", referenced: true},
+		{name: "web Secret certificate is not a ConfigMap", kind: graph.KindConfigMap, target: "web-tls", referenced: false},
+	}
+
+	g := graph.NewReferenceGraph()
+	collectKeySelectorReferences(prometheus, prometheus.Object["spec"], g)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if referenced := g.IsReferenced(tt.kind, "monitoring", tt.target); referenced != tt.referenced {
+				t.Errorf("expected %s %s to be referenced: %v, got %v", tt.kind, tt.target, tt.referenced, referenced)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReferencedNamespaces returns the namespaces other than its own whose orphans can change with an object:
+// the namespaces it references Secrets and ConfigMaps in, or whose references it allows. Objects whose
+// references are resolved through a class, such as PersistentVolumeClaims, read the class with the client.
+// Ingress annotations are looked up in the given annotation references.
+func ReferencedNamespaces(ctx context.Context, c client.Reader, obj client.Object, annotationReferences []AnnotationReference) ([]string, error) {
+	var namespaces []string
+	var err error
+	switch o := obj.(type) {
+	case *networkingv1.Ingress:
+		namespaces = ingressReferencedNamespaces(o, annotationReferences)
+	case *corev1.PersistentVolumeClaim:
+		namespaces, err = claimSecretNamespaces(ctx, c, o)
+	case *unstructured.Unstructured:
+		switch o.GroupVersionKind().GroupKind() {
+		case schema.GroupKind{Group: gatewayGroup, Kind: "Gateway"}:
+			namespaces = gatewayReferencedNamespaces(o)
+		case schema.GroupKind{Group: gatewayGroup, Kind: "ReferenceGrant"}:
+			namespaces = referenceGrantFromNamespaces(o)
+		case schema.GroupKind{Group: snapshotGroup, Kind: "VolumeSnapshot"}:
+			namespaces, err = snapshotSecretNamespaces(ctx, c, o)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces = slices.DeleteFunc(namespaces, func(namespace string) bool {
+		return namespace == "" || namespace == obj.GetNamespace()
+	})
+	slices.Sort(namespaces)
+	return slices.Compact(namespaces), nil
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// optionalKind is a kind served by CRDs that may not be installed, with its versions in order of preference
+type optionalKind struct {
+	schema.GroupKind
+	versions []string
+}
+
+// optionalKinds are the kinds read by the finders that are not built into Kubernetes
+var optionalKinds = []optionalKind{
+	{GroupKind: schema.GroupKind{Group: snapshotGroup, Kind: "VolumeSnapshotClass"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: snapshotGroup, Kind: "VolumeSnapshot"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: gatewayGroup, Kind: "Gateway"}, versions: []string{"v1", "v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: gatewayGroup, Kind: "BackendTLSPolicy"}, versions: []string{"v1", "v1alpha3"}},
+	{GroupKind: schema.GroupKind{Group: gatewayGroup, Kind: "ReferenceGrant"}, versions: []string{"v1", "v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: certManagerGroup, Kind: "Issuer"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: certManagerGroup, Kind: "ClusterIssuer"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: certManagerGroup, Kind: "Certificate"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: externalSecretsGroup, Kind: "ExternalSecret"}, versions: []string{"v1", "v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: externalSecretsGroup, Kind: "PushSecret"}, versions: []string{"v1alpha1"}},
+	{GroupKind: schema.GroupKind{Group: externalSecretsGroup, Kind: "SecretStore"}, versions: []string{"v1", "v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: externalSecretsGroup, Kind: "ClusterSecretStore"}, versions: []string{"v1", "v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: monitoringGroup, Kind: "Prometheus"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: monitoringGroup, Kind: "Alertmanager"}, versions: []string{"v1"}},
+	// v1alpha1 comes first as v1beta1 is only served with the conversion webhook
+	{GroupKind: schema.GroupKind{Group: monitoringGroup, Kind: "AlertmanagerConfig"}, versions: []string{"v1alpha1", "v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: monitoringGroup, Kind: "ServiceMonitor"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: monitoringGroup, Kind: "PodMonitor"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: monitoringGroup, Kind: "Probe"}, versions: []string{"v1"}},
+	{GroupKind: schema.GroupKind{Group: vaultSecretsGroup, Kind: "VaultStaticSecret"}, versions: []string{"v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: vaultSecretsGroup, Kind: "VaultDynamicSecret"}, versions: []string{"v1beta1"}},
+	{GroupKind: schema.GroupKind{Group: fluxSourceGroup, Kind: "GitRepository"}, versions: []string{"v1", "v1beta2"}},
+	{GroupKind: schema.GroupKind{Group: fluxSourceGroup, Kind: "HelmRepository"}, versions: []string{"v1", "v1beta2"}},
+	{GroupKind: schema.GroupKind{Group: fluxSourceGroup, Kind: "OCIRepository"}, versions: []string{"v1", "v1beta2"}},
+	{GroupKind: schema.GroupKind{Group: fluxKustomizeGroup, Kind: "Kustomization"}, versions: []string{"v1", "v1beta2"}},
+	{GroupKind: schema.GroupKind{Group: fluxHelmGroup, Kind: "HelmRelease"}, versions: []string{"v2", "v2beta2", "v2beta1"}},
+}
+
+// ServedKinds maps the optional kinds served by the API server to the version in which they are read
+type ServedKinds map[schema.GroupKind]schema.GroupVersionKind
+
+// ResolveServedKinds looks up which optional kinds the API server serves, picking the first served version
+// of each. Kinds whose CRDs are installed later are only picked up after a restart.
+func ResolveServedKinds(mapper meta.RESTMapper) (ServedKinds, error) {
+	served := ServedKinds{}
+	for _, kind := range optionalKinds {
+		mapping, err := mapper.RESTMapping(kind.GroupKind, kind.versions...)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		served[kind.GroupKind] = mapping.GroupVersionKind
+	}
+
+	return served, nil
+}
+
+// ServesResourceType checks if the kind read by the strategy for a resource type is served.
+// Resource types that are not optional kinds are always served.
+func (k ServedKinds) ServesResourceType(resourceType string) bool {
+	for _, kind := range optionalKinds {
+		if kind.Kind == resourceType {
+			_, served := k[kind.GroupKind]
+			return served
+		}
+	}
+
+	return true
+}
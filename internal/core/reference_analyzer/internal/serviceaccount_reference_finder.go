@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -19,47 +20,65 @@ func NewServiceAccountReferenceFinder(c client.Client) *ServiceAccountReferenceF
 	}
 }
 
-// FindSecretReferences finds all ServiceAccounts that reference the given Secret
-func (f *ServiceAccountReferenceFinder) FindSecretReferences(ctx context.Context, c client.Client, secretName, namespace string) ([]client.Object, error) {
-	var results []client.Object
-
+// CollectReferences adds every Secret referenced by the ServiceAccounts in the namespace to the graph
+func (f *ServiceAccountReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
 	serviceAccountList := &corev1.ServiceAccountList{}
 	if err := c.List(ctx, serviceAccountList, client.InNamespace(namespace)); err != nil {
-		return nil, err
+		return err
 	}
 
 	for i := range serviceAccountList.Items {
-		serviceAccount := &serviceAccountList.Items[i]
-		if f.serviceAccountReferencesSecret(serviceAccount, secretName) {
-			results = append(results, serviceAccount)
-		}
+		f.collectServiceAccountSecretReferences(&serviceAccountList.Items[i], g)
+	}
+
+	secretList := &corev1.SecretList{}
+	if err := c.List(ctx, secretList, client.InNamespace(namespace)); err != nil {
+		return err
 	}
+	f.collectServiceAccountTokenReferences(serviceAccountList.Items, secretList.Items, g)
 
-	return results, nil
+	return nil
 }
 
-// serviceAccountReferencesSecret checks if a ServiceAccount references the given secret
-func (f *ServiceAccountReferenceFinder) serviceAccountReferencesSecret(serviceAccount *corev1.ServiceAccount, secretName string) bool {
+// collectServiceAccountSecretReferences adds the Secrets referenced by a ServiceAccount to the graph.
+// ServiceAccount does not reference ConfigMaps.
+func (f *ServiceAccountReferenceFinder) collectServiceAccountSecretReferences(serviceAccount *corev1.ServiceAccount, g *graph.ReferenceGraph) {
 	// Check secrets[].name (for image pull secrets and mounted secrets)
 	for _, secret := range serviceAccount.Secrets {
-		if secret.Name == secretName {
-			return true
-		}
+		g.AddSecretReference(serviceAccount, serviceAccount.Namespace, secret.Name)
 	}
 
 	// Check imagePullSecrets[].name (for image pull secrets)
 	for _, imagePullSecret := range serviceAccount.ImagePullSecrets {
-		if imagePullSecret.Name == secretName {
-			return true
-		}
+		g.AddSecretReference(serviceAccount, serviceAccount.Namespace, imagePullSecret.Name)
 	}
-
-	return false
 }
 
-// ServiceAccount does not reference ConfigMaps. This method is implemented to satisfy the ReferenceFinderStrategy interface.
-func (f *ServiceAccountReferenceFinder) FindConfigMapReferences(ctx context.Context, c client.Client, configMapName, namespace string) ([]client.Object, error) {
-	return nil, nil
+// collectServiceAccountTokenReferences adds the legacy service account token Secrets to the graph as
+// referenced by their ServiceAccount. Token Secrets point at their ServiceAccount through the
+// kubernetes.io/service-account.name annotation and are not necessarily listed in its secrets.
+// A token whose ServiceAccount was recreated (the UID annotation no longer matches) stays unreferenced.
+func (f *ServiceAccountReferenceFinder) collectServiceAccountTokenReferences(serviceAccounts []corev1.ServiceAccount, secrets []corev1.Secret, g *graph.ReferenceGraph) {
+	serviceAccountsByName := make(map[string]*corev1.ServiceAccount, len(serviceAccounts))
+	for i := range serviceAccounts {
+		serviceAccountsByName[serviceAccounts[i].Name] = &serviceAccounts[i]
+	}
+
+	for _, secret := range secrets {
+		if secret.Type != corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+
+		serviceAccount, exists := serviceAccountsByName[secret.Annotations[corev1.ServiceAccountNameKey]]
+		if !exists {
+			continue
+		}
+		if uid, hasUID := secret.Annotations[corev1.ServiceAccountUIDKey]; hasUID && uid != string(serviceAccount.UID) {
+			continue
+		}
+
+		g.AddSecretReference(serviceAccount, secret.Namespace, secret.Name)
+	}
 }
 
 // GetResourceType returns the Kubernetes resource type this strategy handles
@@ -0,0 +1,316 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StorageResourceType represents a valid cluster-scoped storage resource type
+type StorageResourceType string
+
+const (
+	StorageResourceTypeStorageClass        StorageResourceType = "StorageClass"
+	StorageResourceTypePersistentVolume    StorageResourceType = "PersistentVolume"
+	StorageResourceTypeVolumeSnapshotClass StorageResourceType = "VolumeSnapshotClass"
+)
+
+// snapshotGroup is the API group of the volume snapshot resources, served by the external-snapshotter CRDs
+const snapshotGroup = "snapshot.storage.k8s.io"
+
+// secretParameter is a pair of parameters naming a Secret by its name and namespace
+type secretParameter struct {
+	name, namespace string
+}
+
+var (
+	// storageClassSecretParameters are the StorageClass parameters read by the CSI sidecars
+	storageClassSecretParameters = append(prefixedSecretParameters(
+		"csi.storage.k8s.io/provisioner",
+		"csi.storage.k8s.io/controller-publish",
+		"csi.storage.k8s.io/node-stage",
+		"csi.storage.k8s.io/node-publish",
+		"csi.storage.k8s.io/controller-expand",
+		"csi.storage.k8s.io/node-expand",
+	), deprecatedStorageClassSecretParameters...)
+	// deprecatedStorageClassSecretParameters are the StorageClass parameters that the CSI sidecars still read
+	// from before the csi.storage.k8s.io/ prefix was introduced
+	deprecatedStorageClassSecretParameters = []secretParameter{
+		{name: "csiProvisionerSecretName", namespace: "csiProvisionerSecretNamespace"},
+		{name: "csiControllerPublishSecretName", namespace: "csiControllerPublishSecretNamespace"},
+		{name: "csiNodeStageSecretName", namespace: "csiNodeStageSecretNamespace"},
+		{name: "csiNodePublishSecretName", namespace: "csiNodePublishSecretNamespace"},
+	}
+	// volumeSnapshotClassSecretParameters are the VolumeSnapshotClass parameters read by the CSI snapshotter
+	volumeSnapshotClassSecretParameters = prefixedSecretParameters(
+		"csi.storage.k8s.io/snapshotter",
+		"csi.storage.k8s.io/snapshotter-list",
+	)
+	// provisionerDeletionSecretAnnotations are the PersistentVolume annotations in which the CSI provisioner
+	// keeps the resolved provisioner Secret, read again when the volume is deleted
+	provisionerDeletionSecretAnnotations = []secretParameter{{
+		name:      "volume.kubernetes.io/provisioner-deletion-secret-name",
+		namespace: "volume.kubernetes.io/provisioner-deletion-secret-namespace",
+	}}
+
+	// templateVariable matches a ${...} variable in a templated secret parameter
+	templateVariable = regexp.MustCompile(`\$\{[^}]*\}`)
+)
+
+// StorageReferenceFinder finds references to Secrets in cluster-scoped storage resources
+// (StorageClass, PersistentVolume, VolumeSnapshotClass). It lists them across the cluster, as
+// they can reference Secrets in any namespace.
+type StorageReferenceFinder struct {
+	client.Client
+	resourceType StorageResourceType
+	servedKinds  ServedKinds
+}
+
+// NewStorageReferenceFinder creates a new StorageReferenceFinder for the given resource type
+func NewStorageReferenceFinder(c client.Client, resourceType StorageResourceType, servedKinds ServedKinds) *StorageReferenceFinder {
+	return &StorageReferenceFinder{
+		Client:       c,
+		resourceType: resourceType,
+		servedKinds:  servedKinds,
+	}
+}
+
+// CollectReferences adds every Secret referenced by the storage resources to the graph.
+// The namespace is ignored; references into all namespaces are recorded.
+func (f *StorageReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	switch f.resourceType {
+	case StorageResourceTypeStorageClass:
+		return f.collectStorageClassReferences(ctx, c, g)
+	case StorageResourceTypePersistentVolume:
+		return f.collectPersistentVolumeReferences(ctx, c, g)
+	case StorageResourceTypeVolumeSnapshotClass:
+		return f.collectVolumeSnapshotClassReferences(ctx, c, g)
+	}
+
+	return nil
+}
+
+// collectStorageClassReferences adds the Secrets named in StorageClass parameters to the graph.
+// Templated parameters are resolved against every PersistentVolumeClaim of the StorageClass.
+func (f *StorageReferenceFinder) collectStorageClassReferences(ctx context.Context, c client.Client, g *graph.ReferenceGraph) error {
+	storageClassList := &storagev1.StorageClassList{}
+	if err := c.List(ctx, storageClassList); err != nil {
+		return err
+	}
+	claimList := &corev1.PersistentVolumeClaimList{}
+	if err := c.List(ctx, claimList); err != nil {
+		return err
+	}
+
+	claimsByClass := map[string][]map[string]string{}
+	for _, claim := range claimList.Items {
+		if claim.Spec.StorageClassName == nil {
+			continue
+		}
+		claimsByClass[*claim.Spec.StorageClassName] = append(claimsByClass[*claim.Spec.StorageClassName], claimTemplateValues(&claim))
+	}
+
+	for i := range storageClassList.Items {
+		storageClass := &storageClassList.Items[i]
+		addSecretParameters(storageClass, storageClass.Parameters, storageClassSecretParameters, claimsByClass[storageClass.Name], g)
+	}
+
+	return nil
+}
+
+// collectPersistentVolumeReferences adds the Secrets referenced by CSI PersistentVolumes to the graph
+func (f *StorageReferenceFinder) collectPersistentVolumeReferences(ctx context.Context, c client.Client, g *graph.ReferenceGraph) error {
+	volumeList := &corev1.PersistentVolumeList{}
+	if err := c.List(ctx, volumeList); err != nil {
+		return err
+	}
+
+	for i := range volumeList.Items {
+		volume := &volumeList.Items[i]
+		if volume.Spec.CSI == nil {
+			continue
+		}
+		addSecretParameters(volume, volume.Annotations, provisionerDeletionSecretAnnotations, nil, g)
+		for _, ref := range []*corev1.SecretReference{
+			volume.Spec.CSI.ControllerPublishSecretRef,
+			volume.Spec.CSI.NodeStageSecretRef,
+			volume.Spec.CSI.NodePublishSecretRef,
+			volume.Spec.CSI.ControllerExpandSecretRef,
+			volume.Spec.CSI.NodeExpandSecretRef,
+		} {
+			if ref != nil {
+				g.AddSecretReference(volume, ref.Namespace, ref.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectVolumeSnapshotClassReferences adds the Secrets named in VolumeSnapshotClass parameters to the graph.
+// Templated parameters are resolved against every VolumeSnapshot of the class.
+func (f *StorageReferenceFinder) collectVolumeSnapshotClassReferences(ctx context.Context, c client.Client, g *graph.ReferenceGraph) error {
+	classList, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: snapshotGroup, Kind: "VolumeSnapshotClass"})
+	if err != nil || classList == nil {
+		return err
+	}
+	snapshotList, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: snapshotGroup, Kind: "VolumeSnapshot"})
+	if err != nil {
+		return err
+	}
+	var snapshots []unstructured.Unstructured
+	if snapshotList != nil {
+		snapshots = snapshotList.Items
+	}
+
+	snapshotsByClass := map[string][]map[string]string{}
+	for i := range snapshots {
+		className, _, _ := unstructured.NestedString(snapshots[i].Object, "spec", "volumeSnapshotClassName")
+		snapshotsByClass[className] = append(snapshotsByClass[className], snapshotTemplateValues(&snapshots[i]))
+	}
+
+	for i := range classList.Items {
+		class := &classList.Items[i]
+		parameters, _, _ := unstructured.NestedStringMap(class.Object, "parameters")
+		addSecretParameters(class, parameters, volumeSnapshotClassSecretParameters, snapshotsByClass[class.GetName()], g)
+	}
+
+	return nil
+}
+
+// prefixedSecretParameters returns the <prefix>-secret-name and <prefix>-secret-namespace parameters of the prefixes
+func prefixedSecretParameters(prefixes ...string) []secretParameter {
+	secretParameters := make([]secretParameter, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		secretParameters = append(secretParameters, secretParameter{name: prefix + "-secret-name", namespace: prefix + "-secret-namespace"})
+	}
+	return secretParameters
+}
+
+// addSecretParameters adds the Secrets named by the secret parameters to the graph. Parameters with ${...}
+// variables are resolved once per set of template values; those that still have unresolved variables are skipped.
+func addSecretParameters(consumer client.Object, parameters map[string]string, secretParameters []secretParameter, templateValues []map[string]string, g *graph.ReferenceGraph) {
+	for _, secretParameter := range secretParameters {
+		name := parameters[secretParameter.name]
+		namespace := parameters[secretParameter.namespace]
+		if name == "" || namespace == "" {
+			continue
+		}
+
+		if !templateVariable.MatchString(name) && !templateVariable.MatchString(namespace) {
+			g.AddSecretReference(consumer, namespace, name)
+			continue
+		}
+
+		for _, values := range templateValues {
+			resolvedName, nameOk := resolveTemplate(name, values)
+			resolvedNamespace, namespaceOk := resolveTemplate(namespace, values)
+			if nameOk && namespaceOk {
+				g.AddSecretReference(consumer, resolvedNamespace, resolvedName)
+			}
+		}
+	}
+}
+
+// claimTemplateValues returns the values of the ${pvc.*} and ${pv.name} variables for a PersistentVolumeClaim
+func claimTemplateValues(claim *corev1.PersistentVolumeClaim) map[string]string {
+	values := map[string]string{
+		"pvc.name":      claim.Name,
+		"pvc.namespace": claim.Namespace,
+	}
+	if claim.Spec.VolumeName != "" {
+		values["pv.name"] = claim.Spec.VolumeName
+	}
+	for key, value := range claim.Annotations {
+		values[fmt.Sprintf("pvc.annotations['%s']", key)] = value
+	}
+	return values
+}
+
+// snapshotTemplateValues returns the values of the ${volumesnapshot.*} and ${volumesnapshotcontent.name}
+// variables for a VolumeSnapshot
+func snapshotTemplateValues(snapshot *unstructured.Unstructured) map[string]string {
+	values := map[string]string{
+		"volumesnapshot.name":      snapshot.GetName(),
+		"volumesnapshot.namespace": snapshot.GetNamespace(),
+	}
+	if contentName, _, _ := unstructured.NestedString(snapshot.Object, "status", "boundVolumeSnapshotContentName"); contentName != "" {
+		values["volumesnapshotcontent.name"] = contentName
+	}
+	return values
+}
+
+// claimSecretNamespaces returns the namespaces of the Secrets named by the templated parameters of the
+// StorageClass of a PersistentVolumeClaim, resolved for the claim
+func claimSecretNamespaces(ctx context.Context, c client.Reader, claim *corev1.PersistentVolumeClaim) ([]string, error) {
+	if claim.Spec.StorageClassName == nil {
+		return nil, nil
+	}
+	storageClass := &storagev1.StorageClass{}
+	if err := c.Get(ctx, client.ObjectKey{Name: *claim.Spec.StorageClassName}, storageClass); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return templatedSecretNamespaces(storageClass.Parameters, storageClassSecretParameters, claimTemplateValues(claim)), nil
+}
+
+// snapshotSecretNamespaces returns the namespaces of the Secrets named by the templated parameters of the
+// VolumeSnapshotClass of a VolumeSnapshot, resolved for the snapshot
+func snapshotSecretNamespaces(ctx context.Context, c client.Reader, snapshot *unstructured.Unstructured) ([]string, error) {
+	className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	if className == "" {
+		return nil, nil
+	}
+	class := &unstructured.Unstructured{}
+	class.SetGroupVersionKind(snapshot.GroupVersionKind().GroupVersion().WithKind("VolumeSnapshotClass"))
+	if err := c.Get(ctx, client.ObjectKey{Name: className}, class); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	parameters, _, _ := unstructured.NestedStringMap(class.Object, "parameters")
+	return templatedSecretNamespaces(parameters, volumeSnapshotClassSecretParameters, snapshotTemplateValues(snapshot)), nil
+}
+
+// templatedSecretNamespaces returns the namespaces of the Secrets named by templated secret parameters,
+// resolved with the given template values
+func templatedSecretNamespaces(parameters map[string]string, secretParameters []secretParameter, values map[string]string) []string {
+	var namespaces []string
+	for _, secretParameter := range secretParameters {
+		name := parameters[secretParameter.name]
+		namespace := parameters[secretParameter.namespace]
+		if !templateVariable.MatchString(name) && !templateVariable.MatchString(namespace) {
+			continue
+		}
+
+		_, nameOk := resolveTemplate(name, values)
+		resolvedNamespace, namespaceOk := resolveTemplate(namespace, values)
+		if name != "" && nameOk && namespaceOk {
+			namespaces = append(namespaces, resolvedNamespace)
+		}
+	}
+	return namespaces
+}
+
+// resolveTemplate replaces the ${...} variables of a templated parameter with the given values.
+// It reports false if any variable has no value.
+func resolveTemplate(template string, values map[string]string) (string, bool) {
+	resolved := true
+	result := templateVariable.ReplaceAllStringFunc(template, func(variable string) string {
+		value, exists := values[variable[2:len(variable)-1]]
+		if !exists {
+			resolved = false
+		}
+		return value
+	})
+	return result, resolved
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *StorageReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAddSecretParameters(t *testing.T) {
+	claimValues := []map[string]string{
+		claimTemplateValues(&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "team-a", Annotations: map[string]string{"secret": "data-key"}},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-data"},
+		}),
+		claimTemplateValues(&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "team-b"},
+		}),
+	}
+
+	type secret struct {
+		namespace, name string
+	}
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		referenced []secret
+		ignored    []secret
+	}{
+		{
+			name: "provisioner secret",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/provisioner-secret-name":      "provisioner",
+				"csi.storage.k8s.io/provisioner-secret-namespace": "storage",
+			},
+			referenced: []secret{{"storage", "provisioner"}},
+		},
+		{
+			name: "node-expand secret",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/node-expand-secret-name":      "expand",
+				"csi.storage.k8s.io/node-expand-secret-namespace": "storage",
+			},
+			referenced: []secret{{"storage", "expand"}},
+		},
+		{
+			name: "deprecated provisioner and node-publish secrets",
+			parameters: map[string]string{
+				"csiProvisionerSecretName":      "legacy-provisioner",
+				"csiProvisionerSecretNamespace": "storage",
+				"csiNodePublishSecretName":      "legacy-publish",
+				"csiNodePublishSecretNamespace": "storage",
+			},
+			referenced: []secret{{"storage", "legacy-provisioner"}, {"storage", "legacy-publish"}},
+		},
+		{
+			name: "name without namespace",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/node-stage-secret-name": "stage",
+			},
+			ignored: []secret{{"", "stage"}, {"default", "stage"}},
+		},
+		{
+			name: "namespace template resolved per claim",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/node-stage-secret-name":      "stage",
+				"csi.storage.k8s.io/node-stage-secret-namespace": "${pvc.namespace}",
+			},
+			referenced: []secret{{"team-a", "stage"}, {"team-b", "stage"}},
+		},
+		{
+			name: "name template resolved per claim",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/node-publish-secret-name":      "${pvc.name}-${pv.name}",
+				"csi.storage.k8s.io/node-publish-secret-namespace": "${pvc.namespace}",
+			},
+			referenced: []secret{{"team-a", "data-pv-data"}},
+			ignored:    []secret{{"team-b", "logs-"}},
+		},
+		{
+			name: "annotation template skipped for claims without the annotation",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/controller-publish-secret-name":      "${pvc.annotations['secret']}",
+				"csi.storage.k8s.io/controller-publish-secret-namespace": "${pvc.namespace}",
+			},
+			referenced: []secret{{"team-a", "data-key"}},
+			ignored:    []secret{{"team-b", ""}},
+		},
+		{
+			name: "unknown variable is never resolved",
+			parameters: map[string]string{
+				"csi.storage.k8s.io/provisioner-secret-name":      "${unknown}",
+				"csi.storage.k8s.io/provisioner-secret-namespace": "storage",
+			},
+			ignored: []secret{{"storage", ""}, {"storage", "${unknown}"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageClass := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "csi", UID: types.UID("csi")}}
+
+			g := graph.NewReferenceGraph()
+			addSecretParameters(storageClass, tt.parameters, storageClassSecretParameters, claimValues, g)
+
+			for _, s := range tt.referenced {
+				if !g.IsReferenced(graph.KindSecret, s.namespace, s.name) {
+					t.Errorf("expected Secret %s/%s to be referenced", s.namespace, s.name)
+				}
+			}
+			for _, s := range tt.ignored {
+				if g.IsReferenced(graph.KindSecret, s.namespace, s.name) {
+					t.Errorf("expected Secret %s/%s not to be referenced", s.namespace, s.name)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectReferencesPersistentVolumeDeletionSecret(t *testing.T) {
+	volume := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pv-data",
+			Annotations: map[string]string{
+				"volume.kubernetes.io/provisioner-deletion-secret-name":      "provisioner",
+				"volume.kubernetes.io/provisioner-deletion-secret-namespace": "team-a",
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: &corev1.CSIPersistentVolumeSource{
+				Driver:               "csi.example.com",
+				VolumeHandle:         "volume",
+				NodePublishSecretRef: &corev1.SecretReference{Name: "publish", Namespace: "team-a"},
+			}},
+		},
+	}
+	c := fake.NewClientBuilder().WithObjects(volume).Build()
+	finder := NewStorageReferenceFinder(c, StorageResourceTypePersistentVolume, nil)
+
+	g := graph.NewReferenceGraph()
+	if err := finder.CollectReferences(context.Background(), c, "team-a", g); err != nil {
+		t.Fatalf("CollectReferences returned an error: %v", err)
+	}
+
+	for _, name := range []string{"provisioner", "publish"} {
+		if !g.IsReferenced(graph.KindSecret, "team-a", name) {
+			t.Errorf("expected Secret team-a/%s to be referenced", name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// secretReference is a reference to a Secret found in an unstructured object.
+// The namespace is empty unless the reference sets it.
+type secretReference struct {
+	namespace string
+	name      string
+}
+
+// nestedSecretReferences walks an unstructured value and returns every nested secret reference: an object
+// under a key ending in "SecretRef", or named "secretRef" or "credentialsRef", that has a "name" field.
+// Operators such as cert-manager and External Secrets use this convention for all credentials.
+func nestedSecretReferences(value interface{}) []secretReference {
+	var refs []secretReference
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			if isSecretReferenceKey(key) {
+				if ref, ok := nested.(map[string]interface{}); ok {
+					name, _, _ := unstructured.NestedString(ref, "name")
+					namespace, _, _ := unstructured.NestedString(ref, "namespace")
+					if name != "" {
+						refs = append(refs, secretReference{namespace: namespace, name: name})
+					}
+				}
+			}
+			refs = append(refs, nestedSecretReferences(nested)...)
+		}
+	case []interface{}:
+		for _, nested := range v {
+			refs = append(refs, nestedSecretReferences(nested)...)
+		}
+	}
+
+	return refs
+}
+
+// isSecretReferenceKey checks if a key holds a secret reference by naming convention
+func isSecretReferenceKey(key string) bool {
+	return strings.HasSuffix(key, "SecretRef") || key == "secretRef" || key == "credentialsRef"
+}
+
+// listIfServed lists the objects of an optional kind in the version resolved at startup, or returns nil without
+// an error if the kind is not served. Optional kinds are read as unstructured objects, so kponos does not depend
+// on the modules of the operators that define them, and clusters without their CRDs simply have no references.
+func listIfServed(ctx context.Context, c client.Client, servedKinds ServedKinds, kind schema.GroupKind, opts ...client.ListOption) (*unstructured.UnstructuredList, error) {
+	gvk, served := servedKinds[kind]
+	if !served {
+		return nil, nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := c.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// VaultSecretsResourceType represents a valid Vault Secrets Operator resource type
+type VaultSecretsResourceType string
+
+const (
+	VaultSecretsResourceTypeVaultStaticSecret  VaultSecretsResourceType = "VaultStaticSecret"
+	VaultSecretsResourceTypeVaultDynamicSecret VaultSecretsResourceType = "VaultDynamicSecret"
+)
+
+// vaultSecretsGroup is the API group of the Vault Secrets Operator
+const vaultSecretsGroup = "secrets.hashicorp.com"
+
+// VaultSecretsReferenceFinder finds the destination Secrets that VaultStaticSecrets and VaultDynamicSecrets
+// create and keep in sync, and records them as generated by them
+type VaultSecretsReferenceFinder struct {
+	client.Client
+	resourceType VaultSecretsResourceType
+	servedKinds  ServedKinds
+}
+
+// NewVaultSecretsReferenceFinder creates a new VaultSecretsReferenceFinder for the given resource type
+func NewVaultSecretsReferenceFinder(c client.Client, resourceType VaultSecretsResourceType, servedKinds ServedKinds) *VaultSecretsReferenceFinder {
+	return &VaultSecretsReferenceFinder{
+		Client:       c,
+		resourceType: resourceType,
+		servedKinds:  servedKinds,
+	}
+}
+
+// CollectReferences adds every Secret generated by the Vault Secrets Operator resources to the graph
+func (f *VaultSecretsReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
+	secrets, err := listIfServed(ctx, c, f.servedKinds, schema.GroupKind{Group: vaultSecretsGroup, Kind: string(f.resourceType)}, client.InNamespace(namespace))
+	if err != nil || secrets == nil {
+		return err
+	}
+
+	for i := range secrets.Items {
+		vaultSecret := &secrets.Items[i]
+		// Check spec.destination.name. The Secret is only generated with spec.destination.create; otherwise it
+		// is created by someone else and the operator only writes to it.
+		destinationName, _, _ := unstructured.NestedString(vaultSecret.Object, "spec", "destination", "name")
+		if create, _, _ := unstructured.NestedBool(vaultSecret.Object, "spec", "destination", "create"); create {
+			g.AddGenerator(vaultSecret, graph.KindSecret, vaultSecret.GetNamespace(), destinationName)
+		}
+	}
+
+	return nil
+}
+
+// GetResourceType returns the Kubernetes resource type this strategy handles
+func (f *VaultSecretsReferenceFinder) GetResourceType() string {
+	return string(f.resourceType)
+}
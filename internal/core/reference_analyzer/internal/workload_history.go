@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deploymentRevisionAnnotation holds the rollout revision of a ReplicaSet owned by a Deployment
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// defaultRevisionHistoryLimit is the revisionHistoryLimit of Deployments, StatefulSets and DaemonSets when unset
+const defaultRevisionHistoryLimit = 10
+
+// deploymentHistory returns the old ReplicaSets of the Deployments, keyed by UID. The value reports whether
+// the ReplicaSet is retained within the Deployment's revisionHistoryLimit and can still be rolled back to.
+// A ReplicaSet is old when it is controlled by an existing Deployment and scaled to zero; a Deployment
+// scaled to zero still references everything through its own template.
+func deploymentHistory(replicaSets []appsv1.ReplicaSet, deployments []appsv1.Deployment) map[types.UID]bool {
+	limits := make(map[types.UID]int32, len(deployments))
+	for _, deployment := range deployments {
+		limits[deployment.UID] = historyLimit(deployment.Spec.RevisionHistoryLimit)
+	}
+
+	oldReplicaSets := map[types.UID][]*appsv1.ReplicaSet{}
+	for i := range replicaSets {
+		replicaSet := &replicaSets[i]
+		owner := metav1.GetControllerOf(replicaSet)
+		if owner == nil || owner.Kind != "Deployment" {
+			continue
+		}
+		if _, exists := limits[owner.UID]; !exists {
+			continue
+		}
+		if replicaSet.Spec.Replicas == nil || *replicaSet.Spec.Replicas != 0 {
+			continue
+		}
+		oldReplicaSets[owner.UID] = append(oldReplicaSets[owner.UID], replicaSet)
+	}
+
+	history := map[types.UID]bool{}
+	for owner, candidates := range oldReplicaSets {
+		// Newest revisions first, as the Deployment controller prunes the oldest
+		sort.Slice(candidates, func(i, j int) bool {
+			return replicaSetRevision(candidates[i]) > replicaSetRevision(candidates[j])
+		})
+		for i, replicaSet := range candidates {
+			history[replicaSet.UID] = int32(i) < limits[owner]
+		}
+	}
+
+	return history
+}
+
+// replicaSetRevision returns the rollout revision of a ReplicaSet, or zero if it is unknown
+func replicaSetRevision(replicaSet *appsv1.ReplicaSet) int64 {
+	revision, err := strconv.ParseInt(replicaSet.Annotations[deploymentRevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// revisionHistoryLimits returns the revisionHistoryLimit of every StatefulSet and DaemonSet in the namespace, keyed by UID
+func revisionHistoryLimits(ctx context.Context, c client.Client, namespace string) (map[types.UID]int32, error) {
+	statefulSetList := &appsv1.StatefulSetList{}
+	if err := c.List(ctx, statefulSetList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	daemonSetList := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, daemonSetList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	limits := make(map[types.UID]int32, len(statefulSetList.Items)+len(daemonSetList.Items))
+	for _, statefulSet := range statefulSetList.Items {
+		limits[statefulSet.UID] = historyLimit(statefulSet.Spec.RevisionHistoryLimit)
+	}
+	for _, daemonSet := range daemonSetList.Items {
+		limits[daemonSet.UID] = historyLimit(daemonSet.Spec.RevisionHistoryLimit)
+	}
+	return limits, nil
+}
+
+// retainedControllerRevisions returns the ControllerRevisions of existing owners that are kept within their
+// owner's revisionHistoryLimit. The newest revision is the current one and is always kept on top of the limit.
+func retainedControllerRevisions(controllerRevisions []appsv1.ControllerRevision, limits map[types.UID]int32) []*appsv1.ControllerRevision {
+	revisionsByOwner := map[types.UID][]*appsv1.ControllerRevision{}
+	for i := range controllerRevisions {
+		controllerRevision := &controllerRevisions[i]
+		owner := metav1.GetControllerOf(controllerRevision)
+		if owner == nil {
+			continue
+		}
+		if _, exists := limits[owner.UID]; !exists {
+			continue
+		}
+		revisionsByOwner[owner.UID] = append(revisionsByOwner[owner.UID], controllerRevision)
+	}
+
+	var retained []*appsv1.ControllerRevision
+	for owner, revisions := range revisionsByOwner {
+		sort.Slice(revisions, func(i, j int) bool {
+			return revisions[i].Revision > revisions[j].Revision
+		})
+		keep := min(len(revisions), int(limits[owner])+1)
+		retained = append(retained, revisions[:keep]...)
+	}
+
+	return retained
+}
+
+// controllerRevisionTemplate decodes the Pod template stored in a StatefulSet or DaemonSet ControllerRevision.
+// Both controllers store a patch of the form {"spec":{"template":{...}}}.
+func controllerRevisionTemplate(controllerRevision *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+	var patch struct {
+		Spec struct {
+			Template corev1.PodTemplateSpec `json:"template"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(controllerRevision.Data.Raw, &patch); err != nil {
+		return nil, err
+	}
+	return &patch.Spec.Template, nil
+}
+
+// historyLimit returns the revisionHistoryLimit, defaulting it when unset
+func historyLimit(limit *int32) int32 {
+	if limit == nil {
+		return defaultRevisionHistoryLimit
+	}
+	return *limit
+}
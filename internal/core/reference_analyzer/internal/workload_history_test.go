@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestDeploymentHistory(t *testing.T) {
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "deployment"},
+		Spec:       appsv1.DeploymentSpec{RevisionHistoryLimit: int32Ptr(2)},
+	}
+	replicaSet := func(uid types.UID, revision string, replicas int32, owner *appsv1.Deployment) appsv1.ReplicaSet {
+		replicaSet := appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app-" + string(uid),
+				Namespace:   "default",
+				UID:         uid,
+				Annotations: map[string]string{deploymentRevisionAnnotation: revision},
+			},
+			Spec: appsv1.ReplicaSetSpec{Replicas: int32Ptr(replicas)},
+		}
+		if owner != nil {
+			replicaSet.OwnerReferences = []metav1.OwnerReference{
+				*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind("Deployment")),
+			}
+		}
+		return replicaSet
+	}
+	deletedDeployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "deleted", UID: "deleted"}}
+
+	tests := []struct {
+		name        string
+		replicaSets []appsv1.ReplicaSet
+		deployments []appsv1.Deployment
+		expected    map[types.UID]bool
+	}{
+		{
+			name:        "current ReplicaSet is not history",
+			replicaSets: []appsv1.ReplicaSet{replicaSet("current", "4", 3, &deployment)},
+			deployments: []appsv1.Deployment{deployment},
+			expected:    map[types.UID]bool{},
+		},
+		{
+			name: "old ReplicaSets beyond revisionHistoryLimit are not retained",
+			replicaSets: []appsv1.ReplicaSet{
+				replicaSet("current", "4", 3, &deployment),
+				replicaSet("revision-3", "3", 0, &deployment),
+				replicaSet("revision-2", "2", 0, &deployment),
+				replicaSet("revision-1", "1", 0, &deployment),
+			},
+			deployments: []appsv1.Deployment{deployment},
+			expected:    map[types.UID]bool{"revision-3": true, "revision-2": true, "revision-1": false},
+		},
+		{
+			name:        "default revisionHistoryLimit retains old ReplicaSets",
+			replicaSets: []appsv1.ReplicaSet{replicaSet("revision-1", "1", 0, &deployment)},
+			deployments: []appsv1.Deployment{{ObjectMeta: deployment.ObjectMeta}},
+			expected:    map[types.UID]bool{"revision-1": true},
+		},
+		{
+			name:        "zero revisionHistoryLimit retains no old ReplicaSets",
+			replicaSets: []appsv1.ReplicaSet{replicaSet("revision-1", "1", 0, &deployment)},
+			deployments: []appsv1.Deployment{{
+				ObjectMeta: deployment.ObjectMeta,
+				Spec:       appsv1.DeploymentSpec{RevisionHistoryLimit: int32Ptr(0)},
+			}},
+			expected: map[types.UID]bool{"revision-1": false},
+		},
+		{
+			name:        "ReplicaSet of a deleted Deployment is not history",
+			replicaSets: []appsv1.ReplicaSet{replicaSet("revision-1", "1", 0, deletedDeployment)},
+			deployments: []appsv1.Deployment{deployment},
+			expected:    map[types.UID]bool{},
+		},
+		{
+			name:        "standalone ReplicaSet is not history",
+			replicaSets: []appsv1.ReplicaSet{replicaSet("standalone", "", 0, nil)},
+			deployments: []appsv1.Deployment{deployment},
+			expected:    map[types.UID]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := deploymentHistory(tt.replicaSets, tt.deployments)
+			if len(history) != len(tt.expected) {
+				t.Fatalf("expected %d ReplicaSets in the history, got %v", len(tt.expected), history)
+			}
+			for uid, retained := range tt.expected {
+				if got, exists := history[uid]; !exists || got != retained {
+					t.Errorf("expected ReplicaSet %s retained: %v, got %v (in history: %v)", uid, retained, got, exists)
+				}
+			}
+		})
+	}
+}
+
+func TestRetainedControllerRevisions(t *testing.T) {
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "statefulset"}}
+	deletedStatefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "deleted", Namespace: "default", UID: "deleted"}}
+	controllerRevision := func(name string, revision int64, owner *appsv1.StatefulSet) appsv1.ControllerRevision {
+		return appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))},
+			},
+			Revision: revision,
+		}
+	}
+	revisions := []appsv1.ControllerRevision{
+		controllerRevision("db-1", 1, statefulSet),
+		controllerRevision("db-3", 3, statefulSet),
+		controllerRevision("db-2", 2, statefulSet),
+		controllerRevision("deleted-1", 1, deletedStatefulSet),
+	}
+
+	tests := []struct {
+		name     string
+		limit    int32
+		expected []string
+	}{
+		{name: "current revision is kept on top of a zero limit", limit: 0, expected: []string{"db-3"}},
+		{name: "newest revisions within the limit", limit: 1, expected: []string{"db-3", "db-2"}},
+		{name: "limit above the number of revisions", limit: 10, expected: []string{"db-3", "db-2", "db-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retained := retainedControllerRevisions(revisions, map[types.UID]int32{statefulSet.UID: tt.limit})
+
+			var names []string
+			for _, revision := range retained {
+				names = append(names, revision.Name)
+			}
+			slices.Sort(names)
+			expected := slices.Sorted(slices.Values(tt.expected))
+			if !slices.Equal(names, expected) {
+				t.Errorf("expected retained ControllerRevisions %v, got %v", expected, names)
+			}
+		})
+	}
+}
+
+func TestControllerRevisionTemplate(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		secret    string
+		expectErr bool
+	}{
+		{
+			name:   "StatefulSet patch",
+			data:   `{"spec":{"template":{"$patch":"replace","spec":{"volumes":[{"name":"tls","secret":{"secretName":"db-tls"}}]}}}}`,
+			secret: "db-tls",
+		},
+		{
+			name:   "DaemonSet patch",
+			data:   `{"spec":{"template":{"spec":{"containers":[{"name":"agent","envFrom":[{"secretRef":{"name":"agent-env"}}]}]}}}}`,
+			secret: "agent-env",
+		},
+		{
+			name:      "malformed data",
+			data:      `{"spec":`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controllerRevision := &appsv1.ControllerRevision{Data: runtime.RawExtension{Raw: []byte(tt.data)}}
+
+			template, err := controllerRevisionTemplate(controllerRevision)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatal("expected an error for malformed data")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("controllerRevisionTemplate returned an error: %v", err)
+			}
+
+			var secrets []string
+			for _, volume := range template.Spec.Volumes {
+				if volume.Secret != nil {
+					secrets = append(secrets, volume.Secret.SecretName)
+				}
+			}
+			for _, container := range template.Spec.Containers {
+				for _, envFrom := range container.EnvFrom {
+					if envFrom.SecretRef != nil {
+						secrets = append(secrets, envFrom.SecretRef.Name)
+					}
+				}
+			}
+			if !slices.Contains(secrets, tt.secret) {
+				t.Errorf("expected Secret %s in the decoded template, got %v", tt.secret, secrets)
+			}
+		})
+	}
+}
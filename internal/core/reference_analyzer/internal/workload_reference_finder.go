@@ -3,7 +3,9 @@ package internal
 import (
 	"context"
 
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -16,10 +18,19 @@ const (
 	WorkloadResourceTypeDeployment  WorkloadResourceType = "Deployment"
 	WorkloadResourceTypeStatefulSet WorkloadResourceType = "StatefulSet"
 	WorkloadResourceTypeDaemonSet   WorkloadResourceType = "DaemonSet"
+	WorkloadResourceTypeJob         WorkloadResourceType = "Job"
+	WorkloadResourceTypeCronJob     WorkloadResourceType = "CronJob"
+	WorkloadResourceTypeReplicaSet  WorkloadResourceType = "ReplicaSet"
+	// WorkloadResourceTypeReplicationController is the legacy predecessor of ReplicaSet
+	WorkloadResourceTypeReplicationController WorkloadResourceType = "ReplicationController"
+	WorkloadResourceTypePodTemplate           WorkloadResourceType = "PodTemplate"
+	// WorkloadResourceTypeControllerRevision holds the revision history of StatefulSets and DaemonSets
+	WorkloadResourceTypeControllerRevision WorkloadResourceType = "ControllerRevision"
 )
 
 // WorkloadReferenceFinder finds references to Secrets and ConfigMaps in workload resources
-// that are Pods or create Pods (Deployment, StatefulSet, DaemonSet)
+// that are Pods, create Pods (Deployment, StatefulSet, DaemonSet, Job, CronJob, ReplicaSet,
+// ReplicationController) or hold a Pod template (PodTemplate)
 type WorkloadReferenceFinder struct {
 	client.Client
 	resourceType WorkloadResourceType
@@ -33,205 +44,260 @@ func NewWorkloadReferenceFinder(c client.Client, resourceType WorkloadResourceTy
 	}
 }
 
-// FindSecretReferences finds all resources that reference the given Secret
-func (f *WorkloadReferenceFinder) FindSecretReferences(ctx context.Context, c client.Client, secretName, namespace string) ([]client.Object, error) {
-	var results []client.Object
-
+// CollectReferences adds every Secret and ConfigMap referenced by the workloads in the namespace to the graph
+func (f *WorkloadReferenceFinder) CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error {
 	switch f.resourceType {
 	case WorkloadResourceTypePod:
 		podList := &corev1.PodList{}
 		if err := c.List(ctx, podList, client.InNamespace(namespace)); err != nil {
-			return nil, err
+			return err
 		}
 		for i := range podList.Items {
 			pod := &podList.Items[i]
-			if f.podSpecReferencesSecret(&pod.Spec, secretName) {
-				results = append(results, pod)
-			}
+			f.collectPodSpecReferences(pod, &pod.Spec, g)
 		}
 
 	case WorkloadResourceTypeDeployment:
 		deploymentList := &appsv1.DeploymentList{}
 		if err := c.List(ctx, deploymentList, client.InNamespace(namespace)); err != nil {
-			return nil, err
+			return err
 		}
 		for i := range deploymentList.Items {
 			deployment := &deploymentList.Items[i]
-			if f.podSpecReferencesSecret(&deployment.Spec.Template.Spec, secretName) {
-				results = append(results, deployment)
-			}
+			f.collectPodSpecReferences(deployment, &deployment.Spec.Template.Spec, g)
 		}
 
 	case WorkloadResourceTypeStatefulSet:
 		statefulSetList := &appsv1.StatefulSetList{}
 		if err := c.List(ctx, statefulSetList, client.InNamespace(namespace)); err != nil {
-			return nil, err
+			return err
 		}
 		for i := range statefulSetList.Items {
 			statefulSet := &statefulSetList.Items[i]
-			if f.podSpecReferencesSecret(&statefulSet.Spec.Template.Spec, secretName) {
-				results = append(results, statefulSet)
-			}
+			f.collectPodSpecReferences(statefulSet, &statefulSet.Spec.Template.Spec, g)
 		}
 
 	case WorkloadResourceTypeDaemonSet:
 		daemonSetList := &appsv1.DaemonSetList{}
 		if err := c.List(ctx, daemonSetList, client.InNamespace(namespace)); err != nil {
-			return nil, err
+			return err
 		}
 		for i := range daemonSetList.Items {
 			daemonSet := &daemonSetList.Items[i]
-			if f.podSpecReferencesSecret(&daemonSet.Spec.Template.Spec, secretName) {
-				results = append(results, daemonSet)
-			}
+			f.collectPodSpecReferences(daemonSet, &daemonSet.Spec.Template.Spec, g)
 		}
-	}
 
-	return results, nil
-}
+	case WorkloadResourceTypeJob:
+		jobList := &batchv1.JobList{}
+		if err := c.List(ctx, jobList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		for i := range jobList.Items {
+			job := &jobList.Items[i]
+			f.collectPodSpecReferences(job, &job.Spec.Template.Spec, g)
+		}
 
-// podSpecReferencesSecret checks if a PodSpec references the given secret
-func (f *WorkloadReferenceFinder) podSpecReferencesSecret(podSpec *corev1.PodSpec, secretName string) bool {
-	// Check volumes[].secret.secretName
-	for _, volume := range podSpec.Volumes {
-		if volume.Secret != nil && volume.Secret.SecretName == secretName {
-			return true
+	case WorkloadResourceTypeCronJob:
+		cronJobList := &batchv1.CronJobList{}
+		if err := c.List(ctx, cronJobList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		for i := range cronJobList.Items {
+			cronJob := &cronJobList.Items[i]
+			f.collectPodSpecReferences(cronJob, &cronJob.Spec.JobTemplate.Spec.Template.Spec, g)
 		}
-	}
 
-	// Check containers[].envFrom[].secretRef.name and containers[].env[].valueFrom.secretKeyRef.name
-	for _, container := range podSpec.Containers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
-				return true
-			}
+	case WorkloadResourceTypeReplicaSet:
+		replicaSetList := &appsv1.ReplicaSetList{}
+		if err := c.List(ctx, replicaSetList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		deploymentList := &appsv1.DeploymentList{}
+		if err := c.List(ctx, deploymentList, client.InNamespace(namespace)); err != nil {
+			return err
 		}
-		for _, env := range container.Env {
-			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
-				return true
+		history := deploymentHistory(replicaSetList.Items, deploymentList.Items)
+		for i := range replicaSetList.Items {
+			replicaSet := &replicaSetList.Items[i]
+			if retained, isHistory := history[replicaSet.UID]; isHistory {
+				// Old ReplicaSets of a Deployment are only needed to roll back
+				if retained {
+					f.collectPodSpecReferences(replicaSet, &replicaSet.Spec.Template.Spec, g.History())
+				}
+				continue
 			}
+			f.collectPodSpecReferences(replicaSet, &replicaSet.Spec.Template.Spec, g)
 		}
-	}
 
-	// Check initContainers[].envFrom[].secretRef.name and initContainers[].env[].valueFrom.secretKeyRef.name
-	for _, container := range podSpec.InitContainers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.SecretRef != nil && envFrom.SecretRef.Name == secretName {
-				return true
+	case WorkloadResourceTypeControllerRevision:
+		controllerRevisionList := &appsv1.ControllerRevisionList{}
+		if err := c.List(ctx, controllerRevisionList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		limits, err := revisionHistoryLimits(ctx, c, namespace)
+		if err != nil {
+			return err
+		}
+		for _, controllerRevision := range retainedControllerRevisions(controllerRevisionList.Items, limits) {
+			template, err := controllerRevisionTemplate(controllerRevision)
+			if err != nil {
+				// A revision that cannot be decoded cannot be rolled back to either
+				continue
 			}
+			f.collectPodSpecReferences(controllerRevision, &template.Spec, g.History())
+		}
+
+	case WorkloadResourceTypeReplicationController:
+		replicationControllerList := &corev1.ReplicationControllerList{}
+		if err := c.List(ctx, replicationControllerList, client.InNamespace(namespace)); err != nil {
+			return err
 		}
-		for _, env := range container.Env {
-			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secretName {
-				return true
+		for i := range replicationControllerList.Items {
+			replicationController := &replicationControllerList.Items[i]
+			// The template of a ReplicationController is optional
+			if replicationController.Spec.Template != nil {
+				f.collectPodSpecReferences(replicationController, &replicationController.Spec.Template.Spec, g)
 			}
 		}
-	}
 
-	// Check imagePullSecrets[].name
-	for _, imagePullSecret := range podSpec.ImagePullSecrets {
-		if imagePullSecret.Name == secretName {
-			return true
+	case WorkloadResourceTypePodTemplate:
+		podTemplateList := &corev1.PodTemplateList{}
+		if err := c.List(ctx, podTemplateList, client.InNamespace(namespace)); err != nil {
+			return err
+		}
+		for i := range podTemplateList.Items {
+			podTemplate := &podTemplateList.Items[i]
+			f.collectPodSpecReferences(podTemplate, &podTemplate.Template.Spec, g)
 		}
 	}
 
-	return false
+	return nil
 }
 
-// FindConfigMapReferences finds all resources that reference the given ConfigMap
-func (f *WorkloadReferenceFinder) FindConfigMapReferences(ctx context.Context, c client.Client, configMapName, namespace string) ([]client.Object, error) {
-	var results []client.Object
+// collectPodSpecReferences adds the Secrets and ConfigMaps referenced by a PodSpec to the graph
+func (f *WorkloadReferenceFinder) collectPodSpecReferences(consumer client.Object, podSpec *corev1.PodSpec, g *graph.ReferenceGraph) {
+	f.collectPodSpecSecretReferences(consumer, podSpec, g)
+	f.collectPodSpecConfigMapReferences(consumer, podSpec, g)
+}
 
-	switch f.resourceType {
-	case WorkloadResourceTypePod:
-		podList := &corev1.PodList{}
-		if err := c.List(ctx, podList, client.InNamespace(namespace)); err != nil {
-			return nil, err
-		}
-		for i := range podList.Items {
-			pod := &podList.Items[i]
-			if f.podSpecReferencesConfigMap(&pod.Spec, configMapName) {
-				results = append(results, pod)
-			}
-		}
+// collectPodSpecSecretReferences adds the Secrets referenced by a PodSpec to the graph
+func (f *WorkloadReferenceFinder) collectPodSpecSecretReferences(consumer client.Object, podSpec *corev1.PodSpec, g *graph.ReferenceGraph) {
+	namespace := consumer.GetNamespace()
 
-	case WorkloadResourceTypeDeployment:
-		deploymentList := &appsv1.DeploymentList{}
-		if err := c.List(ctx, deploymentList, client.InNamespace(namespace)); err != nil {
-			return nil, err
+	// Check volumes[].secret.secretName, volumes[].projected.sources[].secret.name and the
+	// credentials of CSI and in-tree volume plugins.
+	// Items only select keys of the Secret, which is referenced as a whole either way.
+	for _, volume := range podSpec.Volumes {
+		if volume.Secret != nil {
+			g.AddSecretReference(consumer, namespace, volume.Secret.SecretName)
 		}
-		for i := range deploymentList.Items {
-			deployment := &deploymentList.Items[i]
-			if f.podSpecReferencesConfigMap(&deployment.Spec.Template.Spec, configMapName) {
-				results = append(results, deployment)
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					g.AddSecretReference(consumer, namespace, source.Secret.Name)
+				}
 			}
 		}
+		g.AddSecretReference(consumer, namespace, volumePluginSecretName(&volume))
+	}
 
-	case WorkloadResourceTypeStatefulSet:
-		statefulSetList := &appsv1.StatefulSetList{}
-		if err := c.List(ctx, statefulSetList, client.InNamespace(namespace)); err != nil {
-			return nil, err
-		}
-		for i := range statefulSetList.Items {
-			statefulSet := &statefulSetList.Items[i]
-			if f.podSpecReferencesConfigMap(&statefulSet.Spec.Template.Spec, configMapName) {
-				results = append(results, statefulSet)
+	// Check envFrom[].secretRef.name and env[].valueFrom.secretKeyRef.name of every container
+	visitContainers(podSpec, func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+		for _, source := range envFrom {
+			if source.SecretRef != nil {
+				g.AddSecretReference(consumer, namespace, source.SecretRef.Name)
 			}
 		}
-
-	case WorkloadResourceTypeDaemonSet:
-		daemonSetList := &appsv1.DaemonSetList{}
-		if err := c.List(ctx, daemonSetList, client.InNamespace(namespace)); err != nil {
-			return nil, err
-		}
-		for i := range daemonSetList.Items {
-			daemonSet := &daemonSetList.Items[i]
-			if f.podSpecReferencesConfigMap(&daemonSet.Spec.Template.Spec, configMapName) {
-				results = append(results, daemonSet)
+		for _, variable := range env {
+			if variable.ValueFrom != nil && variable.ValueFrom.SecretKeyRef != nil {
+				g.AddSecretReference(consumer, namespace, variable.ValueFrom.SecretKeyRef.Name)
 			}
 		}
-	}
+	})
 
-	return results, nil
+	// Check imagePullSecrets[].name
+	for _, imagePullSecret := range podSpec.ImagePullSecrets {
+		g.AddSecretReference(consumer, namespace, imagePullSecret.Name)
+	}
 }
 
-// podSpecReferencesConfigMap checks if a PodSpec references the given configmap
-func (f *WorkloadReferenceFinder) podSpecReferencesConfigMap(podSpec *corev1.PodSpec, configMapName string) bool {
-	// Check volumes[].configMap.name
-	for _, volume := range podSpec.Volumes {
-		if volume.ConfigMap != nil && volume.ConfigMap.Name == configMapName {
-			return true
-		}
-	}
+// collectPodSpecConfigMapReferences adds the ConfigMaps referenced by a PodSpec to the graph
+func (f *WorkloadReferenceFinder) collectPodSpecConfigMapReferences(consumer client.Object, podSpec *corev1.PodSpec, g *graph.ReferenceGraph) {
+	namespace := consumer.GetNamespace()
 
-	// Check containers[].envFrom[].configMapRef.name and containers[].env[].valueFrom.configMapKeyRef.name
-	for _, container := range podSpec.Containers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.ConfigMapRef != nil && envFrom.ConfigMapRef.Name == configMapName {
-				return true
-			}
+	// Check volumes[].configMap.name and volumes[].projected.sources[].configMap.name.
+	// Items only select keys of the ConfigMap, which is referenced as a whole either way.
+	for _, volume := range podSpec.Volumes {
+		if volume.ConfigMap != nil {
+			g.AddConfigMapReference(consumer, namespace, volume.ConfigMap.Name)
 		}
-		for _, env := range container.Env {
-			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == configMapName {
-				return true
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					g.AddConfigMapReference(consumer, namespace, source.ConfigMap.Name)
+				}
 			}
 		}
 	}
 
-	// Check initContainers[].envFrom[].configMapRef.name and initContainers[].env[].valueFrom.configMapKeyRef.name
-	for _, container := range podSpec.InitContainers {
-		for _, envFrom := range container.EnvFrom {
-			if envFrom.ConfigMapRef != nil && envFrom.ConfigMapRef.Name == configMapName {
-				return true
+	// Check envFrom[].configMapRef.name and env[].valueFrom.configMapKeyRef.name of every container
+	visitContainers(podSpec, func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+		for _, source := range envFrom {
+			if source.ConfigMapRef != nil {
+				g.AddConfigMapReference(consumer, namespace, source.ConfigMapRef.Name)
 			}
 		}
-		for _, env := range container.Env {
-			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == configMapName {
-				return true
+		for _, variable := range env {
+			if variable.ValueFrom != nil && variable.ValueFrom.ConfigMapKeyRef != nil {
+				g.AddConfigMapReference(consumer, namespace, variable.ValueFrom.ConfigMapKeyRef.Name)
 			}
 		}
+	})
+}
+
+// volumePluginSecretName returns the name of the Secret holding the credentials of a CSI or
+// in-tree volume plugin, or an empty string if the volume does not reference one
+func volumePluginSecretName(volume *corev1.Volume) string {
+	var ref *corev1.LocalObjectReference
+	switch {
+	case volume.CSI != nil:
+		ref = volume.CSI.NodePublishSecretRef
+	case volume.AzureFile != nil:
+		return volume.AzureFile.SecretName
+	case volume.CephFS != nil:
+		ref = volume.CephFS.SecretRef
+	case volume.RBD != nil:
+		ref = volume.RBD.SecretRef
+	case volume.ISCSI != nil:
+		ref = volume.ISCSI.SecretRef
+	case volume.FlexVolume != nil:
+		ref = volume.FlexVolume.SecretRef
+	case volume.ScaleIO != nil:
+		ref = volume.ScaleIO.SecretRef
+	case volume.StorageOS != nil:
+		ref = volume.StorageOS.SecretRef
+	case volume.Cinder != nil:
+		ref = volume.Cinder.SecretRef
 	}
 
-	return false
+	if ref == nil {
+		return ""
+	}
+	return ref.Name
+}
+
+// visitContainers calls visit with the env and envFrom of every container in a PodSpec:
+// containers, init containers (including sidecars with restartPolicy: Always) and ephemeral containers
+func visitContainers(podSpec *corev1.PodSpec, visit func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource)) {
+	for _, container := range podSpec.Containers {
+		visit(container.Env, container.EnvFrom)
+	}
+	for _, container := range podSpec.InitContainers {
+		visit(container.Env, container.EnvFrom)
+	}
+	for _, container := range podSpec.EphemeralContainers {
+		visit(container.Env, container.EnvFrom)
+	}
 }
 
 // GetResourceType returns the Kubernetes resource type this strategy handles
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCollectReferencesVolumePluginSecrets(t *testing.T) {
+	secretRef := &corev1.LocalObjectReference{Name: "credentials"}
+
+	tests := []struct {
+		name   string
+		source corev1.VolumeSource
+	}{
+		{
+			name:   "csi nodePublishSecretRef",
+			source: corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{Driver: "secrets-store.csi.k8s.io", NodePublishSecretRef: secretRef}},
+		},
+		{
+			name:   "azureFile secretName",
+			source: corev1.VolumeSource{AzureFile: &corev1.AzureFileVolumeSource{SecretName: "credentials", ShareName: "share"}},
+		},
+		{
+			name:   "cephfs secretRef",
+			source: corev1.VolumeSource{CephFS: &corev1.CephFSVolumeSource{Monitors: []string{"10.0.0.1:6789"}, SecretRef: secretRef}},
+		},
+		{
+			name:   "rbd secretRef",
+			source: corev1.VolumeSource{RBD: &corev1.RBDVolumeSource{CephMonitors: []string{"10.0.0.1:6789"}, RBDImage: "image", SecretRef: secretRef}},
+		},
+		{
+			name:   "iscsi secretRef",
+			source: corev1.VolumeSource{ISCSI: &corev1.ISCSIVolumeSource{TargetPortal: "10.0.0.1:3260", IQN: "iqn.2026-01.io.kponos:target", SecretRef: secretRef}},
+		},
+		{
+			name:   "flexVolume secretRef",
+			source: corev1.VolumeSource{FlexVolume: &corev1.FlexVolumeSource{Driver: "example/driver", SecretRef: secretRef}},
+		},
+		{
+			name:   "scaleIO secretRef",
+			source: corev1.VolumeSource{ScaleIO: &corev1.ScaleIOVolumeSource{Gateway: "https://gateway", System: "system", SecretRef: secretRef}},
+		},
+		{
+			name:   "storageos secretRef",
+			source: corev1.VolumeSource{StorageOS: &corev1.StorageOSVolumeSource{VolumeName: "volume", SecretRef: secretRef}},
+		},
+		{
+			name:   "cinder secretRef",
+			source: corev1.VolumeSource{Cinder: &corev1.CinderVolumeSource{VolumeID: "volume", SecretRef: secretRef}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "storage-pod", Namespace: "default"},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "nginx"}},
+					Volumes:    []corev1.Volume{{Name: "data", VolumeSource: tt.source}},
+				},
+			}
+			c := fake.NewClientBuilder().WithObjects(pod).Build()
+			finder := NewWorkloadReferenceFinder(c, WorkloadResourceTypePod)
+
+			g := graph.NewReferenceGraph()
+			if err := finder.CollectReferences(context.Background(), c, "default", g); err != nil {
+				t.Fatalf("CollectReferences returned an error: %v", err)
+			}
+
+			if !g.IsReferenced(graph.KindSecret, "default", "credentials") {
+				t.Errorf("expected Secret default/credentials to be referenced by %s", tt.name)
+			}
+		})
+	}
+}
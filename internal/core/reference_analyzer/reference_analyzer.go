@@ -3,64 +3,154 @@ package core
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/toKrzysztof/kponos/internal/core/reference_analyzer/internal"
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // ReferenceFinderStrategy defines the interface for finding references in a specific resource type
 type ReferenceFinderStrategy interface {
-	// FindSecretReferences finds all resources of this type that reference the given Secret
-	FindSecretReferences(ctx context.Context, c client.Client, secretName, namespace string) ([]client.Object, error)
-
-	// FindConfigMapReferences finds all resources of this type that reference the given ConfigMap
-	FindConfigMapReferences(ctx context.Context, c client.Client, configMapName, namespace string) ([]client.Object, error)
+	// CollectReferences lists all resources of this type in the namespace once and adds
+	// every Secret and ConfigMap they reference to the graph
+	CollectReferences(ctx context.Context, c client.Client, namespace string, g *graph.ReferenceGraph) error
 
 	// GetResourceType returns the Kubernetes resource type this strategy handles
 	GetResourceType() string
 }
 
+// ServedKinds maps the optional kinds served by the API server, such as those of cert-manager or the
+// Gateway API, to the version in which they are read
+type ServedKinds = internal.ServedKinds
+
+// ResolveServedKinds looks up which optional kinds the API server serves
+func ResolveServedKinds(mapper meta.RESTMapper) (ServedKinds, error) {
+	return internal.ResolveServedKinds(mapper)
+}
+
+// AnnotationReference describes an annotation whose value names a Secret or ConfigMap,
+// either as "name" in the namespace of the annotated resource or as "namespace/name"
+type AnnotationReference = internal.AnnotationReference
+
+// DefaultIngressAnnotationReferences are the Ingress annotations of ingress-nginx that reference
+// Secrets or ConfigMaps, in the format read by ParseAnnotationReferences
+const DefaultIngressAnnotationReferences = "nginx.ingress.kubernetes.io/auth-secret=Secret," +
+	"nginx.ingress.kubernetes.io/auth-tls-secret=Secret," +
+	"nginx.ingress.kubernetes.io/proxy-ssl-secret=Secret," +
+	"nginx.ingress.kubernetes.io/custom-headers=ConfigMap," +
+	"nginx.ingress.kubernetes.io/auth-proxy-set-headers=ConfigMap"
+
+// ParseAnnotationReferences parses a comma-separated list of annotation=Kind pairs, where Kind is Secret or ConfigMap
+func ParseAnnotationReferences(value string) ([]AnnotationReference, error) {
+	var references []AnnotationReference
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		annotation, kind, found := strings.Cut(pair, "=")
+		annotation, kind = strings.TrimSpace(annotation), strings.TrimSpace(kind)
+		if !found || annotation == "" {
+			return nil, fmt.Errorf("invalid annotation reference %q: expected annotation=Kind", pair)
+		}
+		if kind != graph.KindSecret && kind != graph.KindConfigMap {
+			return nil, fmt.Errorf("invalid annotation reference %q: kind must be %s or %s", pair, graph.KindSecret, graph.KindConfigMap)
+		}
+		references = append(references, AnnotationReference{Annotation: annotation, Kind: kind})
+	}
+	return references, nil
+}
+
+// DefaultCertManagerClusterResourceNamespace is the default cluster resource namespace of cert-manager,
+// used unless cert-manager runs with a different --cluster-resource-namespace
+const DefaultCertManagerClusterResourceNamespace = "cert-manager"
+
+// Options configures the reference finders
+type Options struct {
+	// ServedKinds are the optional kinds served by the API server. Strategies for other optional kinds are skipped.
+	ServedKinds ServedKinds
+	// IngressAnnotationReferences are the Ingress annotations that reference Secrets or ConfigMaps
+	IngressAnnotationReferences []AnnotationReference
+	// CertManagerClusterResourceNamespace is the namespace in which cert-manager looks up the Secrets of ClusterIssuers
+	CertManagerClusterResourceNamespace string
+}
+
 // ReferenceAnalyzer finds resources that reference Secrets or ConfigMaps
 type ReferenceAnalyzer struct {
 	client.Client
 	strategies map[string]ReferenceFinderStrategy
+	opts       Options
 }
 
 // NewReferenceAnalyzer creates a new ReferenceAnalyzer with all strategies initialized
-func NewReferenceAnalyzer(c client.Client) *ReferenceAnalyzer {
+func NewReferenceAnalyzer(c client.Client, opts Options) *ReferenceAnalyzer {
 	strategies := map[string]ReferenceFinderStrategy{
-		"Pod":            internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypePod),
-		"Deployment":     internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeDeployment),
-		"StatefulSet":    internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeStatefulSet),
-		"DaemonSet":      internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeDaemonSet),
-		"Ingress":        internal.NewIngressReferenceFinder(c),
-		"ServiceAccount": internal.NewServiceAccountReferenceFinder(c),
+		"Pod":                   internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypePod),
+		"Deployment":            internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeDeployment),
+		"StatefulSet":           internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeStatefulSet),
+		"DaemonSet":             internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeDaemonSet),
+		"Job":                   internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeJob),
+		"CronJob":               internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeCronJob),
+		"ReplicaSet":            internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeReplicaSet),
+		"ReplicationController": internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeReplicationController),
+		"PodTemplate":           internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypePodTemplate),
+		"ControllerRevision":    internal.NewWorkloadReferenceFinder(c, internal.WorkloadResourceTypeControllerRevision),
+		"Ingress":               internal.NewIngressReferenceFinder(c, opts.IngressAnnotationReferences),
+		"IngressClass":          internal.NewIngressClassReferenceFinder(c),
+		"Gateway":               internal.NewGatewayReferenceFinder(c, internal.GatewayResourceTypeGateway, opts.ServedKinds),
+		"BackendTLSPolicy":      internal.NewGatewayReferenceFinder(c, internal.GatewayResourceTypeBackendTLSPolicy, opts.ServedKinds),
+		"Issuer":                internal.NewCertManagerReferenceFinder(c, internal.CertManagerResourceTypeIssuer, opts.ServedKinds, opts.CertManagerClusterResourceNamespace),
+		"ClusterIssuer":         internal.NewCertManagerReferenceFinder(c, internal.CertManagerResourceTypeClusterIssuer, opts.ServedKinds, opts.CertManagerClusterResourceNamespace),
+		"Certificate":           internal.NewCertManagerReferenceFinder(c, internal.CertManagerResourceTypeCertificate, opts.ServedKinds, opts.CertManagerClusterResourceNamespace),
+		"ExternalSecret":        internal.NewExternalSecretsReferenceFinder(c, internal.ExternalSecretsResourceTypeExternalSecret, opts.ServedKinds),
+		"PushSecret":            internal.NewExternalSecretsReferenceFinder(c, internal.ExternalSecretsResourceTypePushSecret, opts.ServedKinds),
+		"SecretStore":           internal.NewExternalSecretsReferenceFinder(c, internal.ExternalSecretsResourceTypeSecretStore, opts.ServedKinds),
+		"ClusterSecretStore":    internal.NewExternalSecretsReferenceFinder(c, internal.ExternalSecretsResourceTypeClusterSecretStore, opts.ServedKinds),
+		"VaultStaticSecret":     internal.NewVaultSecretsReferenceFinder(c, internal.VaultSecretsResourceTypeVaultStaticSecret, opts.ServedKinds),
+		"VaultDynamicSecret":    internal.NewVaultSecretsReferenceFinder(c, internal.VaultSecretsResourceTypeVaultDynamicSecret, opts.ServedKinds),
+		"Prometheus":            internal.NewMonitoringReferenceFinder(c, internal.MonitoringResourceTypePrometheus, opts.ServedKinds),
+		"Alertmanager":          internal.NewMonitoringReferenceFinder(c, internal.MonitoringResourceTypeAlertmanager, opts.ServedKinds),
+		"AlertmanagerConfig":    internal.NewMonitoringReferenceFinder(c, internal.MonitoringResourceTypeAlertmanagerConfig, opts.ServedKinds),
+		"ServiceMonitor":        internal.NewMonitoringReferenceFinder(c, internal.MonitoringResourceTypeServiceMonitor, opts.ServedKinds),
+		"PodMonitor":            internal.NewMonitoringReferenceFinder(c, internal.MonitoringResourceTypePodMonitor, opts.ServedKinds),
+		"Probe":                 internal.NewMonitoringReferenceFinder(c, internal.MonitoringResourceTypeProbe, opts.ServedKinds),
+		"GitRepository":         internal.NewGitOpsReferenceFinder(c, internal.GitOpsResourceTypeGitRepository, opts.ServedKinds),
+		"HelmRepository":        internal.NewGitOpsReferenceFinder(c, internal.GitOpsResourceTypeHelmRepository, opts.ServedKinds),
+		"OCIRepository":         internal.NewGitOpsReferenceFinder(c, internal.GitOpsResourceTypeOCIRepository, opts.ServedKinds),
+		"Kustomization":         internal.NewGitOpsReferenceFinder(c, internal.GitOpsResourceTypeKustomization, opts.ServedKinds),
+		"HelmRelease":           internal.NewGitOpsReferenceFinder(c, internal.GitOpsResourceTypeHelmRelease, opts.ServedKinds),
+		"ArgoCD":                internal.NewGitOpsReferenceFinder(c, internal.GitOpsResourceTypeArgoCD, opts.ServedKinds),
+		"ServiceAccount":        internal.NewServiceAccountReferenceFinder(c),
+		"StorageClass":          internal.NewStorageReferenceFinder(c, internal.StorageResourceTypeStorageClass, opts.ServedKinds),
+		"PersistentVolume":      internal.NewStorageReferenceFinder(c, internal.StorageResourceTypePersistentVolume, opts.ServedKinds),
+		"VolumeSnapshotClass":   internal.NewStorageReferenceFinder(c, internal.StorageResourceTypeVolumeSnapshotClass, opts.ServedKinds),
 	}
 
 	return &ReferenceAnalyzer{
 		Client:     c,
 		strategies: strategies,
+		opts:       opts,
 	}
 }
 
-// FindReferencesForSecret finds all resources that reference the given Secret
-// If resourceType is provided, only searches that resource type; otherwise searches all types
-func (s *ReferenceAnalyzer) FindReferencesForSecret(ctx context.Context, secretName, namespace string, resourceType string) ([]client.Object, error) {
+// CollectReferences adds every Secret and ConfigMap referenced by resources of the given type
+// in the namespace to the graph. Resource types whose kind is not served have no references.
+func (s *ReferenceAnalyzer) CollectReferences(ctx context.Context, namespace string, resourceType string, g *graph.ReferenceGraph) error {
 	strategy := s.strategies[resourceType]
 	if strategy == nil {
-		return nil, fmt.Errorf("unknown resource type: %s", resourceType)
+		return fmt.Errorf("unknown resource type: %s", resourceType)
+	}
+	if !s.opts.ServedKinds.ServesResourceType(resourceType) {
+		return nil
 	}
 
-	return strategy.FindSecretReferences(ctx, s.Client, secretName, namespace)
+	return strategy.CollectReferences(ctx, s.Client, namespace, g)
 }
 
-// FindReferencesForConfigMap finds all resources that reference the given ConfigMap
-// If resourceType is provided, only searches that resource type; otherwise searches all types
-func (s *ReferenceAnalyzer) FindReferencesForConfigMap(ctx context.Context, configMapName, namespace string, resourceType string) ([]client.Object, error) {
-	strategy := s.strategies[resourceType]
-	if strategy == nil {
-		return nil, fmt.Errorf("unknown resource type: %s", resourceType)
-	}
-
-	return strategy.FindConfigMapReferences(ctx, s.Client, configMapName, namespace)
+// ReferencedNamespaces returns the namespaces other than its own whose orphans can change with an object,
+// such as the namespaces of the certificates of a Gateway or of the Secrets named in Ingress annotations
+func (s *ReferenceAnalyzer) ReferencedNamespaces(ctx context.Context, obj client.Object) ([]string, error) {
+	return internal.ReferencedNamespaces(ctx, s.Client, obj, s.opts.IngressAnnotationReferences)
 }
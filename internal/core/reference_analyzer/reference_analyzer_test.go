@@ -0,0 +1,94 @@
+package core
+
+import (
+	"slices"
+	"testing"
+
+	graph "github.com/toKrzysztof/kponos/internal/core/reference_graph"
+)
+
+func TestParseAnnotationReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  []AnnotationReference
+		expectErr bool
+	}{
+		{
+			name:  "Secret and ConfigMap",
+			value: "example.com/auth=Secret,example.com/headers=ConfigMap",
+			expected: []AnnotationReference{
+				{Annotation: "example.com/auth", Kind: graph.KindSecret},
+				{Annotation: "example.com/headers", Kind: graph.KindConfigMap},
+			},
+		},
+		{
+			name:     "spaces and empty pairs",
+			value:    " example.com/auth = Secret ,, ",
+			expected: []AnnotationReference{{Annotation: "example.com/auth", Kind: graph.KindSecret}},
+		},
+		{
+			name:  "empty value",
+			value: "",
+		},
+		{
+			name:  "ingress-nginx defaults",
+			value: DefaultIngressAnnotationReferences,
+			expected: []AnnotationReference{
+				{Annotation: "nginx.ingress.kubernetes.io/auth-secret", Kind: graph.KindSecret},
+				{Annotation: "nginx.ingress.kubernetes.io/auth-tls-secret", Kind: graph.KindSecret},
+				{Annotation: "nginx.ingress.kubernetes.io/proxy-ssl-secret", Kind: graph.KindSecret},
+				{Annotation: "nginx.ingress.kubernetes.io/custom-headers", Kind: graph.KindConfigMap},
+				{Annotation: "nginx.ingress.kubernetes.io/auth-proxy-set-headers", Kind: graph.KindConfigMap},
+			},
+		},
+		{
+			name:      "missing kind",
+			value:     "example.com/auth",
+			expectErr: true,
+		},
+		{
+			name:      "empty kind",
+			value:     "example.com/auth=",
+			expectErr: true,
+		},
+		{
+			name:      "missing annotation",
+			value:     "=Secret",
+			expectErr: true,
+		},
+		{
+			name:      "unsupported kind",
+			value:     "example.com/auth=Certificate",
+			expectErr: true,
+		},
+		{
+			name:      "lowercase kind",
+			value:     "example.com/auth=secret",
+			expectErr: true,
+		},
+		{
+			name:      "one malformed pair",
+			value:     "example.com/auth=Secret,example.com/headers",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			references, err := ParseAnnotationReferences(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error for %q, got %v", tt.value, references)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAnnotationReferences returned an error: %v", err)
+			}
+			if !slices.Equal(references, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, references)
+			}
+		})
+	}
+}
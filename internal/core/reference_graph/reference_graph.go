@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KindSecret is the kind of Secret targets in the graph
+	KindSecret = "Secret"
+	// KindConfigMap is the kind of ConfigMap targets in the graph
+	KindConfigMap = "ConfigMap"
+)
+
+// Target identifies a Secret or ConfigMap that can be referenced by other resources
+type Target struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// ReferenceGraph is an in-memory graph of references from consumer resources
+// (Pods, Deployments, Ingresses, ...) to the Secrets and ConfigMaps they use.
+// It is built once per scan so that orphan status can be answered without
+// listing the consumers again for every Secret or ConfigMap.
+// References from revision history (old ReplicaSets, ControllerRevisions) are kept
+// apart, because they are only needed to roll back.
+// Generators are the resources that keep a target in sync from another source, such as an
+// ExternalSecret; they recreate the target if it is deleted, so they do not reference it.
+type ReferenceGraph struct {
+	consumers  map[Target][]client.Object
+	history    map[Target][]client.Object
+	generators map[Target]client.Object
+}
+
+// NewReferenceGraph creates an empty ReferenceGraph
+func NewReferenceGraph() *ReferenceGraph {
+	return &ReferenceGraph{
+		consumers:  map[Target][]client.Object{},
+		history:    map[Target][]client.Object{},
+		generators: map[Target]client.Object{},
+	}
+}
+
+// History returns a view of the graph that records references from revision history.
+// References added to the view are reported by IsReferencedByHistory, not IsReferenced.
+func (g *ReferenceGraph) History() *ReferenceGraph {
+	return &ReferenceGraph{
+		consumers:  g.history,
+		history:    g.history,
+		generators: g.generators,
+	}
+}
+
+// AddReference records that consumer references the target with the given kind, namespace and name
+func (g *ReferenceGraph) AddReference(consumer client.Object, kind, namespace, name string) {
+	if name == "" {
+		return
+	}
+
+	target := Target{Kind: kind, Namespace: namespace, Name: name}
+	for _, existing := range g.consumers[target] {
+		if existing == consumer {
+			return
+		}
+	}
+
+	g.consumers[target] = append(g.consumers[target], consumer)
+}
+
+// AddSecretReference records that consumer references the named Secret in the given namespace
+func (g *ReferenceGraph) AddSecretReference(consumer client.Object, namespace, name string) {
+	g.AddReference(consumer, KindSecret, namespace, name)
+}
+
+// AddConfigMapReference records that consumer references the named ConfigMap in the given namespace
+func (g *ReferenceGraph) AddConfigMapReference(consumer client.Object, namespace, name string) {
+	g.AddReference(consumer, KindConfigMap, namespace, name)
+}
+
+// AddGenerator records that generator creates and keeps the target with the given kind, namespace and name in sync
+func (g *ReferenceGraph) AddGenerator(generator client.Object, kind, namespace, name string) {
+	if name == "" {
+		return
+	}
+
+	g.generators[Target{Kind: kind, Namespace: namespace, Name: name}] = generator
+}
+
+// IsReferenced reports whether any consumer references the given target
+func (g *ReferenceGraph) IsReferenced(kind, namespace, name string) bool {
+	return len(g.consumers[Target{Kind: kind, Namespace: namespace, Name: name}]) > 0
+}
+
+// IsReferencedByHistory reports whether any revision history consumer references the given target
+func (g *ReferenceGraph) IsReferencedByHistory(kind, namespace, name string) bool {
+	return len(g.history[Target{Kind: kind, Namespace: namespace, Name: name}]) > 0
+}
+
+// GetConsumers returns all resources that reference the given target
+func (g *ReferenceGraph) GetConsumers(kind, namespace, name string) []client.Object {
+	return g.consumers[Target{Kind: kind, Namespace: namespace, Name: name}]
+}
+
+// GetGenerator returns the resource that generates the given target, or nil if it is not generated
+func (g *ReferenceGraph) GetGenerator(kind, namespace, name string) client.Object {
+	return g.generators[Target{Kind: kind, Namespace: namespace, Name: name}]
+}
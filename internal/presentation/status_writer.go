@@ -2,13 +2,27 @@ package presentation
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	orphanagev1alpha1 "github.com/toKrzysztof/kponos/api/v1alpha1"
+	application "github.com/toKrzysztof/kponos/internal/application/orphanage"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// fieldOwner is the field manager used for server-side apply of policy statuses
+const fieldOwner = "kponos"
+
+// maxListedResources is the maximum number of entries in the Excluded and RollbackOnly lists of a policy or
+// of a namespace. Both lists are informational, so they are cut to keep the status of a policy scanning many
+// namespaces well below the size limit of etcd objects. Orphans and pending resources are always listed, as
+// their first-seen times are tracked in the status.
+const maxListedResources = 50
+
 // StatusWriter handles writing status updates to OrphanagePolicy resources
 type StatusWriter struct {
 	client.Client
@@ -21,17 +35,350 @@ func NewStatusWriter(c client.Client) *StatusWriter {
 	}
 }
 
-// UpdateStatus updates the status of an OrphanagePolicy
-func (s *StatusWriter) UpdateStatus(ctx context.Context, policy *orphanagev1alpha1.OrphanagePolicy, orphans []client.Object) error {
-	now := time.Now()
+// UpdateStatus updates the status of an OrphanagePolicy after a successful scan
+func (s *StatusWriter) UpdateStatus(ctx context.Context, policy *orphanagev1alpha1.OrphanagePolicy, report application.OrphanReport, scanDuration time.Duration) error {
+	status := policy.Status.DeepCopy()
+	setScanStatus(&status.ScanStatus, policy.Generation, scanDuration, orphanagev1alpha1.ReasonScanSucceeded, nil)
+
+	orphans, err := toTrackedOrphans(ctx, s.Client, report.Orphans)
+	if err != nil {
+		return err
+	}
+	pending, err := toTrackedOrphans(ctx, s.Client, report.Pending)
+	if err != nil {
+		return err
+	}
+	if status.LastChanged.IsZero() || !sameOrphanSet(status.Orphans, orphans) {
+		status.LastChanged = metav1.NewTime(time.Now()).Rfc3339Copy()
+	}
+
+	status.OrphanCount = len(orphans)
+	status.Orphans = orphans
+	status.PendingCount = len(report.Pending)
+	status.Pending = pending
+	var excludedTruncated, rollbackOnlyTruncated bool
+	status.ExcludedCount = len(report.Excluded)
+	status.Excluded, excludedTruncated = toListedOrphans(report.Excluded)
+	status.RollbackOnlyCount = len(report.RollbackOnly)
+	status.RollbackOnly, rollbackOnlyTruncated = toListedOrphans(report.RollbackOnly)
+	status.ListsTruncated = excludedTruncated || rollbackOnlyTruncated
+
+	return s.applyStatus(ctx, policy, status)
+}
+
+// UpdateClusterStatus updates the status of a ClusterOrphanagePolicy with the reports of each scanned namespace
+func (s *StatusWriter) UpdateClusterStatus(ctx context.Context, policy *orphanagev1alpha1.ClusterOrphanagePolicy, reportsByNamespace map[string]application.OrphanReport, scanDuration time.Duration) error {
+	status := policy.Status.DeepCopy()
+	setScanStatus(&status.ScanStatus, policy.Generation, scanDuration, orphanagev1alpha1.ReasonScanSucceeded, nil)
+
+	namespaces := make([]string, 0, len(reportsByNamespace))
+	for namespace := range reportsByNamespace {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	var previousOrphans, orphans []orphanagev1alpha1.Orphan
+	for _, namespace := range status.Namespaces {
+		previousOrphans = append(previousOrphans, namespace.Orphans...)
+	}
+
+	status.OrphanCount = 0
+	status.PendingCount = 0
+	status.ExcludedCount = 0
+	status.RollbackOnlyCount = 0
+	status.Namespaces = make([]orphanagev1alpha1.NamespaceOrphans, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		report := reportsByNamespace[namespace]
+		namespaceOrphans, err := toTrackedOrphans(ctx, s.Client, report.Orphans)
+		if err != nil {
+			return err
+		}
+		pending, err := toTrackedOrphans(ctx, s.Client, report.Pending)
+		if err != nil {
+			return err
+		}
+		orphans = append(orphans, namespaceOrphans...)
+
+		status.OrphanCount += len(report.Orphans)
+		status.PendingCount += len(report.Pending)
+		status.ExcludedCount += len(report.Excluded)
+		status.RollbackOnlyCount += len(report.RollbackOnly)
+		excluded, excludedTruncated := toListedOrphans(report.Excluded)
+		rollbackOnly, rollbackOnlyTruncated := toListedOrphans(report.RollbackOnly)
+		status.Namespaces = append(status.Namespaces, orphanagev1alpha1.NamespaceOrphans{
+			Namespace:         namespace,
+			OrphanCount:       len(report.Orphans),
+			Orphans:           namespaceOrphans,
+			PendingCount:      len(report.Pending),
+			Pending:           pending,
+			ExcludedCount:     len(report.Excluded),
+			Excluded:          excluded,
+			RollbackOnlyCount: len(report.RollbackOnly),
+			RollbackOnly:      rollbackOnly,
+			ListsTruncated:    excludedTruncated || rollbackOnlyTruncated,
+		})
+	}
+
+	if status.LastChanged.IsZero() || !sameOrphanSet(previousOrphans, orphans) {
+		status.LastChanged = metav1.NewTime(time.Now()).Rfc3339Copy()
+	}
+
+	return s.applyClusterStatus(ctx, policy, status)
+}
+
+// UpdateFailedStatus records a failed scan in the status of an OrphanagePolicy.
+// The previously reported orphans are kept, but the conditions mark them as stale.
+func (s *StatusWriter) UpdateFailedStatus(ctx context.Context, policy *orphanagev1alpha1.OrphanagePolicy, reason string, scanErr error, scanDuration time.Duration) error {
+	status := policy.Status.DeepCopy()
+	setScanStatus(&status.ScanStatus, policy.Generation, scanDuration, reason, scanErr)
+	if status.LastChanged.IsZero() {
+		status.LastChanged = metav1.NewTime(time.Now()).Rfc3339Copy()
+	}
+	return s.applyStatus(ctx, policy, status)
+}
+
+// UpdateClusterFailedStatus records a failed scan in the status of a ClusterOrphanagePolicy.
+// The previously reported orphans are kept, but the conditions mark them as stale.
+func (s *StatusWriter) UpdateClusterFailedStatus(ctx context.Context, policy *orphanagev1alpha1.ClusterOrphanagePolicy, reason string, scanErr error, scanDuration time.Duration) error {
+	status := policy.Status.DeepCopy()
+	setScanStatus(&status.ScanStatus, policy.Generation, scanDuration, reason, scanErr)
+	if status.LastChanged.IsZero() {
+		status.LastChanged = metav1.NewTime(time.Now()).Rfc3339Copy()
+	}
+	return s.applyClusterStatus(ctx, policy, status)
+}
+
+// applyStatus writes the status of an OrphanagePolicy with server-side apply, unless it only
+// differs from the current status in the scan duration. Skipping unchanged writes keeps status
+// updates from triggering further reconciles and API server traffic.
+func (s *StatusWriter) applyStatus(ctx context.Context, policy *orphanagev1alpha1.OrphanagePolicy, status *orphanagev1alpha1.OrphanagePolicyStatus) error {
+	current := policy.Status.DeepCopy()
+	current.LastScanDuration = status.LastScanDuration
+	if equality.Semantic.DeepEqual(current, status) {
+		return nil
+	}
+
+	patch := &orphanagev1alpha1.OrphanagePolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: orphanagev1alpha1.GroupVersion.String(),
+			Kind:       "OrphanagePolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      policy.Name,
+			Namespace: policy.Namespace,
+		},
+		Status: *status,
+	}
+	if err := s.Status().Patch(ctx, patch, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+		return err
+	}
+
+	policy.Status = *status
+	return nil
+}
+
+// applyClusterStatus writes the status of a ClusterOrphanagePolicy with server-side apply, unless it
+// only differs from the current status in the scan duration.
+func (s *StatusWriter) applyClusterStatus(ctx context.Context, policy *orphanagev1alpha1.ClusterOrphanagePolicy, status *orphanagev1alpha1.ClusterOrphanagePolicyStatus) error {
+	current := policy.Status.DeepCopy()
+	current.LastScanDuration = status.LastScanDuration
+	if equality.Semantic.DeepEqual(current, status) {
+		return nil
+	}
+
+	patch := &orphanagev1alpha1.ClusterOrphanagePolicy{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: orphanagev1alpha1.GroupVersion.String(),
+			Kind:       "ClusterOrphanagePolicy",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: policy.Name,
+		},
+		Status: *status,
+	}
+	if err := s.Status().Patch(ctx, patch, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership); err != nil {
+		return err
+	}
+
+	policy.Status = *status
+	return nil
+}
+
+// sameOrphanSet reports whether two sorted orphan lists contain the same resources
+func sameOrphanSet(a, b []orphanagev1alpha1.Orphan) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Kind != b[i].Kind || a[i].Namespace != b[i].Namespace || a[i].Name != b[i].Name || a[i].UID != b[i].UID {
+			return false
+		}
+	}
+	return true
+}
+
+// setScanStatus records the outcome of a scan in the conditions and scan fields of a policy status.
+// A nil scanErr marks the scan as succeeded.
+func setScanStatus(status *orphanagev1alpha1.ScanStatus, generation int64, scanDuration time.Duration, reason string, scanErr error) {
+	status.ObservedGeneration = generation
+	status.LastScanDuration = &metav1.Duration{Duration: scanDuration}
+
+	if scanErr == nil {
+		status.LastError = ""
+		setCondition(status, generation, orphanagev1alpha1.ConditionReady, metav1.ConditionTrue, reason, "Orphans are up to date")
+		setCondition(status, generation, orphanagev1alpha1.ConditionScanSucceeded, metav1.ConditionTrue, reason, "Scan completed")
+		setCondition(status, generation, orphanagev1alpha1.ConditionDegraded, metav1.ConditionFalse, reason, "Scan completed")
+		return
+	}
+
+	status.LastError = scanErr.Error()
+	setCondition(status, generation, orphanagev1alpha1.ConditionReady, metav1.ConditionFalse, reason, scanErr.Error())
+	setCondition(status, generation, orphanagev1alpha1.ConditionScanSucceeded, metav1.ConditionFalse, reason, scanErr.Error())
+	setCondition(status, generation, orphanagev1alpha1.ConditionDegraded, metav1.ConditionTrue, reason, scanErr.Error())
+}
+
+// setCondition sets a condition on a policy status, keeping its transition time if the status did not change
+func setCondition(status *orphanagev1alpha1.ScanStatus, generation int64, conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// FirstSeenOrphaned returns the first-seen times of the orphaned and pending resources recorded
+// in an OrphanagePolicy status, so that they survive controller restarts
+func FirstSeenOrphaned(policy *orphanagev1alpha1.OrphanagePolicy) map[application.OrphanKey]time.Time {
+	firstSeen := map[application.OrphanKey]time.Time{}
+	addFirstSeen(firstSeen, policy.Namespace, policy.Status.Orphans)
+	addFirstSeen(firstSeen, policy.Namespace, policy.Status.Pending)
+	return firstSeen
+}
+
+// ClusterFirstSeenOrphaned returns the first-seen times of the orphaned and pending resources recorded
+// in a ClusterOrphanagePolicy status, so that they survive controller restarts
+func ClusterFirstSeenOrphaned(policy *orphanagev1alpha1.ClusterOrphanagePolicy) map[application.OrphanKey]time.Time {
+	firstSeen := map[application.OrphanKey]time.Time{}
+	for _, namespace := range policy.Status.Namespaces {
+		addFirstSeen(firstSeen, namespace.Namespace, namespace.Orphans)
+		addFirstSeen(firstSeen, namespace.Namespace, namespace.Pending)
+	}
+	return firstSeen
+}
+
+// addFirstSeen records the first-seen times of status entries in the given namespace
+func addFirstSeen(firstSeen map[application.OrphanKey]time.Time, namespace string, orphans []orphanagev1alpha1.Orphan) {
+	for _, orphan := range orphans {
+		if orphan.FirstSeenOrphaned == nil {
+			continue
+		}
+		key := application.OrphanKey{Kind: orphan.Kind, Namespace: namespace, Name: orphan.Name, UID: orphan.UID}
+		firstSeen[key] = orphan.FirstSeenOrphaned.Time
+	}
+}
+
+// creatorLabelKeys are the labels that identify the tool that created a resource
+var creatorLabelKeys = []string{
+	"app.kubernetes.io/managed-by",
+	"app.kubernetes.io/instance",
+	"app.kubernetes.io/part-of",
+	"helm.sh/chart",
+	"argocd.argoproj.io/instance",
+	"kustomize.toolkit.fluxcd.io/name",
+	"kustomize.toolkit.fluxcd.io/namespace",
+	"owner",
+}
+
+// toTrackedOrphans converts tracked orphans into their status representation, explaining why each
+// of them is orphaned, sorted by kind, namespace and name
+func toTrackedOrphans(ctx context.Context, c client.Reader, orphans []application.TrackedOrphan) ([]orphanagev1alpha1.Orphan, error) {
+	result := make([]orphanagev1alpha1.Orphan, len(orphans))
+	for i, orphan := range orphans {
+		firstSeen := metav1.NewTime(orphan.FirstSeenOrphaned).Rfc3339Copy()
+		result[i] = toOrphan(orphan.Object)
+		result[i].FirstSeenOrphaned = &firstSeen
+		reason, message, err := application.ExplainOrphan(ctx, c, orphan.Object)
+		if err != nil {
+			return nil, err
+		}
+		result[i].Reason, result[i].Message = reason, message
+	}
+	sortOrphans(result)
+	return result, nil
+}
 
-	policy.Status.OrphanCount = len(orphans)
-	policy.Status.LastChanged = metav1.NewTime(now)
-	policy.Status.Orphans = make([]orphanagev1alpha1.Orphan, len(orphans))
+// toOrphans converts objects into their status representation, sorted by kind, namespace and name
+func toOrphans(orphans []client.Object) []orphanagev1alpha1.Orphan {
+	result := make([]orphanagev1alpha1.Orphan, len(orphans))
 	for i, orphan := range orphans {
-		policy.Status.Orphans[i].Kind = orphan.GetObjectKind().GroupVersionKind().Kind
-		policy.Status.Orphans[i].Name = orphan.GetName()
+		result[i] = toOrphan(orphan)
+	}
+	sortOrphans(result)
+	return result
+}
+
+// toListedOrphans converts objects into their status representation like toOrphans, keeping at most
+// maxListedResources entries. It reports whether entries were dropped.
+func toListedOrphans(orphans []client.Object) ([]orphanagev1alpha1.Orphan, bool) {
+	result := toOrphans(orphans)
+	if len(result) <= maxListedResources {
+		return result, false
+	}
+	return result[:maxListedResources], true
+}
+
+// sortOrphans sorts status entries by kind, namespace and name so that the status is deterministic
+func sortOrphans(orphans []orphanagev1alpha1.Orphan) {
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Kind != orphans[j].Kind {
+			return orphans[i].Kind < orphans[j].Kind
+		}
+		if orphans[i].Namespace != orphans[j].Namespace {
+			return orphans[i].Namespace < orphans[j].Namespace
+		}
+		return orphans[i].Name < orphans[j].Name
+	})
+}
+
+// toOrphan converts an object into its status representation with everything needed
+// to triage it without looking it up again
+func toOrphan(obj client.Object) orphanagev1alpha1.Orphan {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	creationTimestamp := obj.GetCreationTimestamp()
+
+	orphan := orphanagev1alpha1.Orphan{
+		APIVersion:        gvk.GroupVersion().String(),
+		Kind:              gvk.Kind,
+		Namespace:         obj.GetNamespace(),
+		Name:              obj.GetName(),
+		UID:               obj.GetUID(),
+		CreationTimestamp: &creationTimestamp,
+		CreatorLabels:     creatorLabels(obj.GetLabels()),
 	}
 
-	return s.Status().Update(ctx, policy)
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		orphan.Type = string(o.Type)
+		orphan.SizeBytes = application.PayloadSize(o)
+	case *corev1.ConfigMap:
+		orphan.SizeBytes = application.PayloadSize(o)
+	}
+
+	return orphan
+}
+
+// creatorLabels returns the subset of labels that identify the tool that created a resource
+func creatorLabels(labels map[string]string) map[string]string {
+	var result map[string]string
+	for _, key := range creatorLabelKeys {
+		if value, exists := labels[key]; exists {
+			if result == nil {
+				result = map[string]string{}
+			}
+			result[key] = value
+		}
+	}
+	return result
 }
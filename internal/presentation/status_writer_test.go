@@ -0,0 +1,112 @@
+package presentation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	orphanagev1alpha1 "github.com/toKrzysztof/kponos/api/v1alpha1"
+	application "github.com/toKrzysztof/kponos/internal/application/orphanage"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestSameOrphanSet(t *testing.T) {
+	orphan := func(kind, namespace, name, uid string) orphanagev1alpha1.Orphan {
+		return orphanagev1alpha1.Orphan{Kind: kind, Namespace: namespace, Name: name, UID: types.UID(uid)}
+	}
+	secret := orphan("Secret", "default", "credentials", "uid-1")
+	configMap := orphan("ConfigMap", "default", "settings", "uid-2")
+
+	withDetails := secret
+	withDetails.SizeBytes = 128
+	withDetails.CreatorLabels = map[string]string{"app.kubernetes.io/managed-by": "Helm"}
+	withDetails.Reason = application.ReasonUnreferenced
+
+	tests := []struct {
+		name     string
+		a, b     []orphanagev1alpha1.Orphan
+		expected bool
+	}{
+		{name: "both empty", expected: true},
+		{name: "nil and empty", a: nil, b: []orphanagev1alpha1.Orphan{}, expected: true},
+		{name: "same resources", a: []orphanagev1alpha1.Orphan{configMap, secret}, b: []orphanagev1alpha1.Orphan{configMap, secret}, expected: true},
+		{name: "details are ignored", a: []orphanagev1alpha1.Orphan{secret}, b: []orphanagev1alpha1.Orphan{withDetails}, expected: true},
+		{name: "resource added", a: []orphanagev1alpha1.Orphan{secret}, b: []orphanagev1alpha1.Orphan{configMap, secret}, expected: false},
+		{name: "resource removed", a: []orphanagev1alpha1.Orphan{configMap, secret}, b: []orphanagev1alpha1.Orphan{secret}, expected: false},
+		{name: "recreated resource", a: []orphanagev1alpha1.Orphan{secret}, b: []orphanagev1alpha1.Orphan{orphan("Secret", "default", "credentials", "uid-3")}, expected: false},
+		{name: "other kind", a: []orphanagev1alpha1.Orphan{secret}, b: []orphanagev1alpha1.Orphan{orphan("ConfigMap", "default", "credentials", "uid-1")}, expected: false},
+		{name: "other namespace", a: []orphanagev1alpha1.Orphan{secret}, b: []orphanagev1alpha1.Orphan{orphan("Secret", "other", "credentials", "uid-1")}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := sameOrphanSet(tt.a, tt.b); same != tt.expected {
+				t.Errorf("expected same orphan set: %v, got %v", tt.expected, same)
+			}
+		})
+	}
+}
+
+func TestUpdateStatusSkipsUnchangedStatus(t *testing.T) {
+	now := time.Now()
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+			Type:       corev1.SecretTypeOpaque,
+		}
+	}
+	report := func(names ...string) application.OrphanReport {
+		var report application.OrphanReport
+		for _, name := range names {
+			report.Orphans = append(report.Orphans, application.TrackedOrphan{Object: secret(name), FirstSeenOrphaned: now})
+		}
+		return report
+	}
+
+	tests := []struct {
+		name          string
+		report        application.OrphanReport
+		scanDuration  time.Duration
+		expectedWrite bool
+	}{
+		{name: "first scan", report: report("credentials"), scanDuration: time.Second, expectedWrite: true},
+		{name: "only the scan duration changed", report: report("credentials"), scanDuration: 2 * time.Second, expectedWrite: false},
+		{name: "new orphan", report: report("credentials", "tokens"), scanDuration: 2 * time.Second, expectedWrite: true},
+		{name: "orphan removed", report: report("tokens"), scanDuration: 3 * time.Second, expectedWrite: true},
+		{name: "same orphans again", report: report("tokens"), scanDuration: time.Second, expectedWrite: false},
+	}
+
+	policy := &orphanagev1alpha1.OrphanagePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "default", Generation: 1},
+	}
+	writes := 0
+	c := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+			writes++
+			return nil
+		},
+	}).Build()
+	writer := NewStatusWriter(c)
+
+	// The cases run in order against the same policy, each starting from the status written by the previous one
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := writes
+			if err := writer.UpdateStatus(context.Background(), policy, tt.report, tt.scanDuration); err != nil {
+				t.Fatalf("UpdateStatus returned an error: %v", err)
+			}
+			if written := writes > before; written != tt.expectedWrite {
+				t.Errorf("expected status write: %v, got %v", tt.expectedWrite, written)
+			}
+			if policy.Status.OrphanCount != len(tt.report.Orphans) {
+				t.Errorf("expected %d orphans in the status, got %d", len(tt.report.Orphans), policy.Status.OrphanCount)
+			}
+		})
+	}
+}
@@ -12,8 +12,8 @@ metadata:
   namespace: test-orphanage
 spec:
   resourceTypes:
-    - Secret
-    - ConfigMap
+    - kind: Secret
+    - kind: ConfigMap
 EOF
 
 # 3. Check if the policy was created
@@ -185,6 +185,154 @@ kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.
 
 
 
+# -------- CASE 8: ConfigMaps-only policy with exclusions --------
+
+# Create a policy that only scans ConfigMaps and ignores generated ones
+kubectl apply -f - <<EOF
+apiVersion: orphanage.kponos.io/v1alpha1
+kind: OrphanagePolicy
+metadata:
+  name: configmaps-only-policy
+  namespace: test-orphanage
+spec:
+  resourceTypes:
+    - kind: ConfigMap
+      selector:
+        matchExpressions:
+          - key: generated
+            operator: DoesNotExist
+      excludeNames:
+        - kube-root-ca.crt
+      minAge: 10s
+EOF
+
+# Create a generated configmap that should be ignored
+kubectl create configmap generated-configmap \
+  --from-literal=key1=value1 \
+  -n test-orphanage
+kubectl label configmap generated-configmap generated=true -n test-orphanage
+
+# Verify no Secrets, generated ConfigMaps or excluded names are reported
+kubectl get orphanagepolicy configmaps-only-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.kind=="Secret")]}'
+kubectl get orphanagepolicy configmaps-only-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="generated-configmap")]}'
+kubectl get orphanagepolicy configmaps-only-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="kube-root-ca.crt")]}'
+# Should all return nothing
+
+
+
+# -------- CASE 9: Intentionally unreferenced Secrets --------
+
+# Exclude backup keys by name pattern
+kubectl patch orphanagepolicy test-policy -n test-orphanage --type merge -p '{"spec":{"exclusions":{"namePatterns":["backup-*"]}}}'
+
+# Create a backup secret and a secret marked with the ignore annotation
+kubectl create secret generic backup-key \
+  --from-literal=key=abc123 \
+  -n test-orphanage
+kubectl create secret generic ci-token \
+  --from-literal=token=abc123 \
+  -n test-orphanage
+kubectl annotate secret ci-token kponos.io/ignore=true -n test-orphanage
+
+# Verify they are NOT orphaned but listed as excluded
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="backup-key")]}'
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.excluded[*].name}'
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.excludedCount}'
+
+
+
+# -------- CASE 10: Grace period before reporting an orphan --------
+
+# Hold orphans back until they have been unreferenced for a minute
+kubectl patch orphanagepolicy test-policy -n test-orphanage --type merge -p '{"spec":{"gracePeriod":"1m"}}'
+
+# Create a secret that is not referenced yet
+kubectl create secret generic rollout-secret \
+  --from-literal=key1=value1 \
+  -n test-orphanage
+
+# Verify it is pending with a firstSeenOrphaned timestamp, not orphaned
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.pending[?(@.name=="rollout-secret")]}'
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="rollout-secret")]}'
+# Should return nothing
+
+# After a minute it is reported as an orphan, keeping the original timestamp
+sleep 60
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="rollout-secret")].firstSeenOrphaned}'
+
+
+
+# -------- CASE 11: Secret and ConfigMap mounted through a projected volume --------
+
+# Create a secret and a configmap
+kubectl create secret generic projected-secret \
+  --from-literal=tls.crt=cert \
+  -n test-orphanage
+kubectl create configmap projected-configmap \
+  --from-literal=ca.crt=ca \
+  -n test-orphanage
+
+# Create a pod that projects both into one volume, mapping only some keys
+kubectl apply -n test-orphanage -f - <<EOF
+apiVersion: v1
+kind: Pod
+metadata:
+  name: projected-pod
+spec:
+  containers:
+    - name: app
+      image: nginx:latest
+      volumeMounts:
+        - name: bundle
+          mountPath: /etc/bundle
+  volumes:
+    - name: bundle
+      projected:
+        sources:
+          - secret:
+              name: projected-secret
+              items:
+                - key: tls.crt
+                  path: tls.crt
+          - configMap:
+              name: projected-configmap
+EOF
+
+# Verify neither is in the orphans or pending list
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="projected-secret")]}'
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.pending[?(@.name=="projected-configmap")]}'
+# Should return nothing
+
+
+
+# -------- CASE 12: ConfigMap only referenced by Deployment revision history --------
+
+# Create two configmaps and a deployment using the first one
+kubectl create configmap app-config-v1 \
+  --from-literal=version=1 \
+  -n test-orphanage
+kubectl create configmap app-config-v2 \
+  --from-literal=version=2 \
+  -n test-orphanage
+kubectl create deployment history-deployment \
+  --image=nginx:latest \
+  -n test-orphanage
+kubectl patch deployment history-deployment -n test-orphanage --type json \
+  -p '[{"op":"add","path":"/spec/template/spec/containers/0/envFrom","value":[{"configMapRef":{"name":"app-config-v1"}}]}]'
+
+# Roll forward to the second configmap
+kubectl patch deployment history-deployment -n test-orphanage --type json \
+  -p '[{"op":"replace","path":"/spec/template/spec/containers/0/envFrom/0/configMapRef/name","value":"app-config-v2"}]'
+kubectl rollout status deployment/history-deployment -n test-orphanage
+
+# Verify app-config-v1 is rollback-only, not orphaned
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.rollbackOnly[?(@.name=="app-config-v1")]}'
+# Should return the app-config-v1 entry
+kubectl get orphanagepolicy test-policy -n test-orphanage -o jsonpath='{.status.orphans[?(@.name=="app-config-v1")]}'
+# Should return nothing
+
+
+
 # -------- VERIFICATION --------
 
 # Watch the policy status in real-time